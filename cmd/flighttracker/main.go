@@ -1,33 +1,166 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/subham/flighttracker/internal/aircraft"
+	"github.com/subham/flighttracker/internal/airlines"
+	"github.com/subham/flighttracker/internal/airports"
 	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
 	"github.com/subham/flighttracker/internal/tracker"
 	"github.com/subham/flighttracker/internal/ui"
 )
 
 func main() {
-	log.Println("SFO Flight Tracker starting...")
+	log.Println("Flight Tracker starting...")
 
-	// Build provider chain (waterfall: AeroAPI → OpenSky → AviationStack)
+	// Cancelled on Ctrl-C/SIGTERM or when the window is closed; stops the
+	// tracker, feed readers and in-flight requests.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	airportsFromEnv(ctx)
+
+	// Extra or corrected airports, in the embedded dataset's CSV format
+	if path := os.Getenv("AIRPORTS_FILE"); path != "" {
+		n, err := airports.LoadFile(path)
+		if err != nil {
+			log.Fatalf("AIRPORTS_FILE: %v", err)
+		}
+		log.Printf("Airports: loaded %d from %s (%d total)", n, path, airports.Len())
+	}
+
+	// Local airline additions and overrides, in the embedded registry's CSV format
+	if path := os.Getenv("AIRLINES_FILE"); path != "" {
+		n, err := airlines.LoadFile(path)
+		if err != nil {
+			log.Fatalf("AIRLINES_FILE: %v", err)
+		}
+		log.Printf("Airlines: loaded %d from %s (%d total)", n, path, airlines.Len())
+	}
+
+	// Local routes by callsign, checked before any online route lookup
+	var routes []provider.RouteProvider
+	if path := os.Getenv("ROUTES_FILE"); path != "" {
+		table, err := provider.LoadRouteTable(path)
+		if err != nil {
+			log.Fatalf("ROUTES_FILE: %v", err)
+		}
+		log.Printf("Routes: loaded %d from %s", table.Len(), path)
+		routes = append(routes, table)
+	}
+
+	stations := stationsFromEnv()
+	for _, st := range stations {
+		station.Register(st)
+		log.Printf("Station: %v", st)
+	}
+
+	// Replay a recorded session instead of live providers (offline demos, bug repro)
+	if path := os.Getenv("REPLAY_FILE"); path != "" {
+		speed := 1.0
+		if v := os.Getenv("REPLAY_SPEED"); v != "" {
+			if _, err := fmt.Sscanf(v, "%g", &speed); err != nil {
+				log.Fatalf("REPLAY_SPEED %q: %v", v, err)
+			}
+		}
+		replay, err := provider.NewReplayProvider(path, speed)
+		if err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		replay.Loop = true
+		log.Printf("Replaying %s at %gx", path, speed)
+		run(ctx, replay, routes, stations)
+		return
+	}
+
+	// Synthetic traffic instead of live providers (load testing, no API budget)
+	if v := os.Getenv("SIM_AIRCRAFT"); v != "" {
+		var opts provider.SimOptions
+		if _, err := fmt.Sscanf(v, "%d", &opts.Aircraft); err != nil {
+			log.Fatalf("SIM_AIRCRAFT %q: %v", v, err)
+		}
+		// Optional "lat,lon" to move the simulated airport, e.g. onto the antimeridian
+		if c := os.Getenv("SIM_CENTER"); c != "" {
+			if _, err := fmt.Sscanf(c, "%f,%f", &opts.CenterLat, &opts.CenterLon); err != nil {
+				log.Fatalf("SIM_CENTER %q: %v", c, err)
+			}
+		}
+		log.Printf("Simulating %d aircraft", opts.Aircraft)
+		run(ctx, provider.NewSimProvider(opts), routes, stations)
+		return
+	}
+
+	// Build provider chain (waterfall: SBS → Beast → readsb → AeroAPI → OpenSky → AviationStack)
 	var providers []provider.FlightProvider
 
+	// 0. Local ADS-B receiver (dump1090 SBS-1 feed, no quota)
+	if addr := os.Getenv("SBS_ADDR"); addr != "" {
+		log.Printf("SBS: enabled (%s)", addr)
+		sbs := provider.NewSBSProvider(addr)
+		go sbs.Run(ctx)
+		providers = append(providers, sbs)
+	}
+
+	// 0b. Local receiver raw Beast feed (decoded in-process, no quota)
+	if addr := os.Getenv("BEAST_ADDR"); addr != "" {
+		log.Printf("Beast: enabled (%s)", addr)
+		beast := provider.NewBeastProvider(addr)
+		// Optional "lat,lon" of the antenna for single-frame CPR decoding
+		if loc := os.Getenv("BEAST_RECEIVER"); loc != "" {
+			var lat, lon float64
+			if _, err := fmt.Sscanf(loc, "%f,%f", &lat, &lon); err == nil {
+				beast.SetReceiverLocation(lat, lon)
+			} else {
+				log.Printf("Beast: ignoring BEAST_RECEIVER %q: %v", loc, err)
+			}
+		}
+		go beast.Run(ctx)
+		providers = append(providers, beast)
+	}
+
+	// 0c. Local readsb/dump1090-fa aircraft.json (URL or file path, no quota)
+	if src := os.Getenv("READSB_SOURCE"); src != "" {
+		log.Printf("readsb: enabled (%s)", src)
+		providers = append(providers, provider.NewReadsbProvider(src))
+	}
+
 	// 1. AeroAPI (best data, paid)
 	if key := os.Getenv("AEROAPI_KEY"); key != "" {
 		log.Printf("AeroAPI: enabled (key: %s...%s)", key[:4], key[len(key)-4:])
-		providers = append(providers, provider.NewAeroAPIProvider(key))
+		aero := provider.NewAeroAPIProvider(key)
+		// Optional monthly spend cap in USD, e.g. "25"
+		if v := os.Getenv("AEROAPI_BUDGET"); v != "" {
+			var usd float64
+			if _, err := fmt.Sscanf(v, "%g", &usd); err != nil || usd <= 0 {
+				log.Fatalf("AEROAPI_BUDGET %q: must be a positive dollar amount", v)
+			}
+			aero.SetMonthlyBudget(usd)
+		}
+		providers = append(providers, aero)
 	}
 
 	// 2. OpenSky Network (free, no key required)
 	openskyUser := os.Getenv("OPENSKY_USER")
 	openskyPass := os.Getenv("OPENSKY_PASS")
 	log.Printf("OpenSky: enabled (auth: %v)", openskyUser != "")
-	providers = append(providers, provider.NewOpenSkyProvider(openskyUser, openskyPass))
+	// Cached so the featured position comes from the discovery snapshot
+	// instead of another states/all request
+	opensky := provider.NewOpenSkyProvider(openskyUser, openskyPass)
+	opensky.Home = stations[0].Code
+	providers = append(providers, provider.NewCachingProvider(opensky))
 
 	// 3. AviationStack (free tier: 100 req/month)
 	if key := os.Getenv("AVIATIONSTACK_KEY"); key != "" {
@@ -45,27 +178,232 @@ func main() {
 	prov := provider.NewMultiProvider(providers...)
 
 	// Configure rate limits
-	prov.SetRateLimit("aeroapi", 10, time.Minute)       // 10 requests per minute
-	prov.SetRateLimit("opensky", 4000, 24*time.Hour)    // 4000 requests per day
-	prov.SetRateLimit("aviationstack", 3, 24*time.Hour) // ~100/month ≈ conservatively 3/day
+	prov.SetRateLimit("aeroapi", 10, time.Minute)    // 10 requests per minute
+	prov.SetRateLimit("opensky", 4000, 24*time.Hour) // 4000 credits per day; a query costs 1-4 by area
+	prov.SetMonthlyRateLimit("aviationstack", 100)   // free tier, resets each calendar month
+
+	// Keep the windows across restarts so a reboot doesn't reset the budgets
+	// (a provider whose saved window is unreadable is logged and starts afresh)
+	if home, err := os.UserHomeDir(); err == nil {
+		_ = prov.PersistRateLimits(filepath.Join(home, ".flighttracker", "ratelimits"))
+		// Windows are written a few seconds after they change; write the
+		// last changes on the way out
+		defer func() {
+			if err := prov.FlushRateLimits(); err != nil {
+				log.Printf("[ratelimit] %v", err)
+			}
+		}()
+	}
+
+	// Route lookups for position-only flights go through the chain too, so
+	// they are rate-limited and budgeted as background requests
+	routes = append(routes, prov)
+
+	// Fuse all providers' results (e.g. receiver positions + AeroAPI routes)
+	// instead of taking the first provider that answers
+	if os.Getenv("PROVIDER_MERGE") != "" {
+		prov.Merge = true
+		log.Printf("Provider merge: enabled")
+	}
+
+	// Optionally record every provider result for later replay
+	var flightSource provider.FlightProvider = prov
+	if path := os.Getenv("RECORD_FILE"); path != "" {
+		rec, err := provider.NewRecordingProvider(prov, path)
+		if err != nil {
+			log.Fatalf("recorder: %v", err)
+		}
+		defer rec.Close()
+		log.Printf("Recording provider results to %s", path)
+		flightSource = rec
+	}
+
+	run(ctx, flightSource, routes, stations)
+}
+
+// stationsFromEnv returns the watched airports, primary first. STATION picks
+// airports from the airport database by ICAO or IATA code: one ("KOAK"), a
+// comma-separated list ("SFO,OAK,SJC") or a group ("BAY"); the default is SFO.
+// STATION_CENTER ("lat,lon"), STATION_RADIUS (nm), STATION_LABEL and
+// STATION_ZOOM override the primary station's fields, which also allows a
+// single airport that isn't in the database.
+func stationsFromEnv() []station.Station {
+	stations := []station.Station{station.Default()}
+	if spec := os.Getenv("STATION"); spec != "" {
+		known, err := station.LookupList(spec)
+		switch {
+		case err == nil:
+			stations = known
+		case strings.Contains(spec, ",") || os.Getenv("STATION_CENTER") == "":
+			log.Fatalf("STATION %q: %v (set STATION_CENTER for a single custom airport)", spec, err)
+		default:
+			code := strings.ToUpper(spec)
+			stations[0] = station.Station{Code: code, Label: code, RadiusNM: stations[0].RadiusNM}
+		}
+	}
+
+	st := &stations[0]
+	if c := os.Getenv("STATION_CENTER"); c != "" {
+		if _, err := fmt.Sscanf(c, "%f,%f", &st.Lat, &st.Lon); err != nil {
+			log.Fatalf("STATION_CENTER %q: %v", c, err)
+		}
+	}
+	if v := os.Getenv("STATION_RADIUS"); v != "" {
+		if _, err := fmt.Sscanf(v, "%g", &st.RadiusNM); err != nil || st.RadiusNM <= 0 {
+			log.Fatalf("STATION_RADIUS %q: must be a positive number of nautical miles", v)
+		}
+	}
+	if v := os.Getenv("STATION_LABEL"); v != "" {
+		st.Label = v
+	}
+	if v := os.Getenv("STATION_ZOOM"); v != "" {
+		if _, err := fmt.Sscanf(v, "%g", &st.Zoom); err != nil {
+			log.Fatalf("STATION_ZOOM %q: %v", v, err)
+		}
+	}
+	return stations
+}
+
+// airportsFromEnv loads OurAirports' world airport database over the
+// embedded one, so any airport can be a station or route endpoint. AIRPORTS_DB
+// is the directory holding its CSV export (default ~/.flighttracker/airports).
+// With AIRPORTS_DB_URL set (e.g. to airports.OurAirportsURL), the export is
+// downloaded in the background when missing or more than 30 days old and
+// loaded once it arrives; startup never waits for it. Without an export only
+// the embedded major airports are known.
+func airportsFromEnv(ctx context.Context) {
+	dir := os.Getenv("AIRPORTS_DB")
+	if dir == "" {
+		dir = airports.DefaultDir()
+	}
+	url := os.Getenv("AIRPORTS_DB_URL")
+
+	load := func() {
+		n, err := airports.LoadOurAirports(dir)
+		if err != nil {
+			// No export at the default path just means it isn't set up.
+			if !errors.Is(err, fs.ErrNotExist) || os.Getenv("AIRPORTS_DB") != "" {
+				log.Printf("Airports: %v", err)
+			}
+			return
+		}
+		log.Printf("Airports: loaded %d from %s (%d total)", n, dir, airports.Len())
+	}
+	load()
+
+	if url != "" && airports.Stale(dir, 30*24*time.Hour) {
+		go func() {
+			if err := airports.Update(ctx, url, dir); err != nil {
+				log.Printf("Airports: update failed, using %s: %v", dir, err)
+				return
+			}
+			log.Printf("Airports: downloaded from %s", url)
+			load()
+		}()
+	}
+}
+
+// aircraftFromEnv returns the offline aircraft registry, loading it in the
+// background so a large dump doesn't delay startup. AIRCRAFT_DB is the CSV
+// dump (default ~/.flighttracker/aircraft.csv); with AIRCRAFT_DB_URL set, it
+// is downloaded from there when missing or more than 30 days old.
+func aircraftFromEnv(ctx context.Context) *aircraft.DB {
+	path := os.Getenv("AIRCRAFT_DB")
+	if path == "" {
+		path = aircraft.DefaultPath()
+	}
+	url := os.Getenv("AIRCRAFT_DB_URL")
+
+	db := aircraft.NewDB()
+	go func() {
+		if url != "" && aircraft.Stale(path, 30*24*time.Hour) {
+			n, err := db.Update(ctx, url, path)
+			if err == nil {
+				log.Printf("Aircraft: downloaded %d from %s", n, url)
+				return
+			}
+			log.Printf("Aircraft: update failed, using %s: %v", path, err)
+		}
+		n, err := db.LoadFile(path)
+		if err != nil {
+			// No dump at the default path just means the registry isn't set up.
+			if !errors.Is(err, fs.ErrNotExist) || os.Getenv("AIRCRAFT_DB") != "" {
+				log.Printf("Aircraft: %v", err)
+			}
+			return
+		}
+		log.Printf("Aircraft: loaded %d from %s", n, path)
+	}()
+	return db
+}
+
+// enrichPipeline builds the tracker's enrichment: offline registries first,
+// then the network lookups, which only run for the featured flight.
+func enrichPipeline(ctx context.Context, routes []provider.RouteProvider) *tracker.Pipeline {
+	pipe := tracker.NewPipeline()
+	pipe.Add(tracker.AirlineEnricher{}, tracker.StageOptions{})
+	pipe.Add(&tracker.AircraftEnricher{DB: aircraftFromEnv(ctx)}, tracker.StageOptions{})
+	if len(routes) > 0 {
+		pipe.Add(&tracker.RouteEnricher{Sources: routes}, tracker.StageOptions{Every: 10 * time.Second, FeaturedOnly: true})
+	}
+	pipe.Add(tracker.CountryEnricher{}, tracker.StageOptions{})
+	pipe.Add(tracker.FleetEnricher{}, tracker.StageOptions{Every: 2 * time.Second, FeaturedOnly: true})
+	return pipe
+}
+
+// run starts the tracker on the given provider and blocks running the UI.
+// routes are asked, in order, for the featured flight's route when the
+// provider doesn't report it. It returns once the window is closed or ctx is
+// cancelled and the tracker has stopped.
+func run(ctx context.Context, prov provider.FlightProvider, routes []provider.RouteProvider, stations []station.Station) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// Create tracker
 	t := tracker.New(prov)
+	t.Stations = stations
 	// Only track flights from known passenger airlines
-	t.AirlineFilter = ui.IsKnownAirline
+	t.AirlineFilter = airlines.IsPassenger
+	t.Enrich = enrichPipeline(ctx, routes)
+	// Spread provider quota over the hours the display is watched, e.g. "6-23"
+	if v := os.Getenv("ACTIVE_HOURS"); v != "" {
+		from, to, err := tracker.ParseActiveHours(v)
+		if err != nil {
+			log.Fatalf("ACTIVE_HOURS: %v", err)
+		}
+		t.Scheduler.ActiveFrom, t.Scheduler.ActiveTo = from, to
+	}
 
 	// Start tracker in background
-	go t.Run()
+	trackerDone := make(chan struct{})
+	go func() {
+		defer close(trackerDone)
+		t.Run(ctx)
+	}()
 
 	// Create and run the Ebitengine game
-	game := ui.NewGame(t)
+	game := ui.NewGame(ctx, t)
+	// Step through the watched airports unattended, e.g. "30s"
+	if v := os.Getenv("STATION_CYCLE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("STATION_CYCLE %q: %v", v, err)
+		}
+		game.CycleEvery = d
+	}
 
-	ebiten.SetWindowTitle("SFO Flight Tracker")
+	ebiten.SetWindowTitle(station.Span(stations).Label + " Flight Tracker")
 	ebiten.SetWindowSize(1920, 1080)
 	ebiten.SetTPS(30)
 	ebiten.SetVsyncEnabled(true)
 
 	if err := ebiten.RunGame(game); err != nil {
-		log.Fatalf("fatal: %v", err)
+		log.Printf("fatal: %v", err)
 	}
+
+	// Window closed or signal received — stop the tracker before returning so
+	// deferred cleanup (e.g. closing the recording) sees no further writes.
+	cancel()
+	<-trackerDone
+	log.Println("Flight Tracker stopped")
 }
@@ -1,6 +1,7 @@
 package aeroapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,13 +32,13 @@ func NewClient(apiKey string) *Client {
 }
 
 // doRequest performs an authenticated GET request and decodes the JSON response.
-func (c *Client) doRequest(path string, params url.Values, dest any) error {
+func (c *Client) doRequest(ctx context.Context, path string, params url.Values, dest any) error {
 	u := baseURL + path
 	if len(params) > 0 {
 		u += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequest(http.MethodGet, u, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return fmt.Errorf("aeroapi: creating request: %w", err)
 	}
@@ -63,13 +64,13 @@ func (c *Client) doRequest(path string, params url.Values, dest any) error {
 
 // GetArrivals fetches recent arrivals for the given airport (e.g., "KSFO").
 // It filters to airline-type flights only.
-func (c *Client) GetArrivals(airportCode string) ([]Flight, error) {
+func (c *Client) GetArrivals(ctx context.Context, airportCode string) ([]Flight, error) {
 	params := url.Values{
 		"type":      {"Airline"},
 		"max_pages": {"1"},
 	}
 	var resp ArrivalsResponse
-	if err := c.doRequest("/airports/"+airportCode+"/flights/arrivals", params, &resp); err != nil {
+	if err := c.doRequest(ctx, "/airports/"+airportCode+"/flights/arrivals", params, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Arrivals, nil
@@ -77,22 +78,22 @@ func (c *Client) GetArrivals(airportCode string) ([]Flight, error) {
 
 // GetDepartures fetches recent departures for the given airport (e.g., "KSFO").
 // It filters to airline-type flights only.
-func (c *Client) GetDepartures(airportCode string) ([]Flight, error) {
+func (c *Client) GetDepartures(ctx context.Context, airportCode string) ([]Flight, error) {
 	params := url.Values{
 		"type":      {"Airline"},
 		"max_pages": {"1"},
 	}
 	var resp DeparturesResponse
-	if err := c.doRequest("/airports/"+airportCode+"/flights/departures", params, &resp); err != nil {
+	if err := c.doRequest(ctx, "/airports/"+airportCode+"/flights/departures", params, &resp); err != nil {
 		return nil, err
 	}
 	return resp.Departures, nil
 }
 
 // GetFlightPosition fetches the latest position for a given fa_flight_id.
-func (c *Client) GetFlightPosition(faFlightID string) (*InFlightPosition, error) {
+func (c *Client) GetFlightPosition(ctx context.Context, faFlightID string) (*InFlightPosition, error) {
 	var resp InFlightPosition
-	if err := c.doRequest("/flights/"+url.PathEscape(faFlightID)+"/position", nil, &resp); err != nil {
+	if err := c.doRequest(ctx, "/flights/"+url.PathEscape(faFlightID)+"/position", nil, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -100,13 +101,13 @@ func (c *Client) GetFlightPosition(faFlightID string) (*InFlightPosition, error)
 
 // GetAllFlights fetches all flights (scheduled + completed arrivals/departures) for an airport.
 // This is the combined endpoint that includes en-route flights in scheduled_arrivals.
-func (c *Client) GetAllFlights(airportCode string) (*AirportFlightsResponse, error) {
+func (c *Client) GetAllFlights(ctx context.Context, airportCode string) (*AirportFlightsResponse, error) {
 	params := url.Values{
 		"type":      {"Airline"},
 		"max_pages": {"1"},
 	}
 	var resp AirportFlightsResponse
-	if err := c.doRequest("/airports/"+airportCode+"/flights", params, &resp); err != nil {
+	if err := c.doRequest(ctx, "/airports/"+airportCode+"/flights", params, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -0,0 +1,301 @@
+// Package aircraft is an offline registry of individual airframes keyed by
+// ICAO24 transponder address: registration, type, manufacturer, model,
+// operator and build year. It is loaded from a CSV dump such as OpenSky's
+// aircraft database, so lookups need no network and return immediately.
+package aircraft
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Aircraft is one airframe in the registry.
+type Aircraft struct {
+	ICAO24       string // transponder hex address, lower case
+	Registration string // tail number, e.g. "N37281"
+	TypeCode     string // ICAO type designator, e.g. "B789"
+	Manufacturer string // e.g. "Boeing"
+	Model        string // e.g. "787-9 Dreamliner"
+	Operator     string // e.g. "United Airlines"
+	Built        int    // year of manufacture, 0 if unknown
+}
+
+// DB is an in-memory aircraft registry. The zero value is empty; a nil *DB
+// finds nothing. It is safe for concurrent use, and lookups are not blocked
+// while a new dump loads.
+type DB struct {
+	mu    sync.RWMutex
+	byHex map[string]Aircraft
+	gen   int // successful loads
+}
+
+// NewDB returns an empty registry.
+func NewDB() *DB {
+	return &DB{}
+}
+
+// DefaultPath is where the registry dump is kept unless configured otherwise.
+func DefaultPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".flighttracker", "aircraft.csv")
+}
+
+// Lookup returns the airframe with the given ICAO24 address.
+func (d *DB) Lookup(icao24 string) (Aircraft, bool) {
+	if d == nil {
+		return Aircraft{}, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	a, ok := d.byHex[strings.ToLower(strings.TrimSpace(icao24))]
+	return a, ok
+}
+
+// Len returns the number of airframes loaded.
+func (d *DB) Len() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.byHex)
+}
+
+// Generation returns the number of dumps loaded so far. It changes whenever
+// the contents do, so callers can tell that an earlier miss may now be found.
+func (d *DB) Generation() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.gen
+}
+
+// LoadFile replaces the registry with the dump at path. See Load.
+func (d *DB) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("aircraft: %w", err)
+	}
+	defer f.Close()
+	n, err := d.Load(f)
+	if err != nil {
+		return 0, fmt.Errorf("aircraft: %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// Load replaces the registry with a CSV dump. Columns are found by header
+// name, so both OpenSky's dumps (double- or single-quoted) and a minimal
+// file with icao24,registration,typecode,manufacturer,model,operator,built
+// work. Rows with neither a registration nor a type code are skipped. On
+// error the previous contents are kept.
+func (d *DB) Load(r io.Reader) (int, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	quote := byte('"')
+	if first, err := br.Peek(1); err == nil && first[0] == '\'' {
+		quote = '\''
+	}
+	rows := newRowReader(br, quote)
+
+	header, err := rows.next()
+	if err != nil {
+		return 0, fmt.Errorf("reading header: %w", err)
+	}
+	cols := columnsFor(header)
+	if cols.icao24 < 0 {
+		return 0, fmt.Errorf("no icao24 column in header")
+	}
+
+	byHex := make(map[string]Aircraft)
+	for {
+		rec, err := rows.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		a := cols.parse(rec)
+		if len(a.ICAO24) != 6 || (a.Registration == "" && a.TypeCode == "") {
+			continue
+		}
+		byHex[a.ICAO24] = a
+	}
+
+	d.mu.Lock()
+	d.byHex = byHex
+	d.gen++
+	d.mu.Unlock()
+	return len(byHex), nil
+}
+
+// Update downloads a fresh dump from url to path, replacing the file only
+// once the download completes, and loads it.
+func (d *DB) Update(ctx context.Context, url, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("aircraft: creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("aircraft: download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("aircraft: download HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("aircraft: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".aircraft-*.csv")
+	if err != nil {
+		return 0, fmt.Errorf("aircraft: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("aircraft: download failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, fmt.Errorf("aircraft: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("aircraft: %w", err)
+	}
+	return d.LoadFile(path)
+}
+
+// Stale reports whether the dump at path is missing or older than maxAge.
+func Stale(path string, maxAge time.Duration) bool {
+	info, err := os.Stat(path)
+	return err != nil || time.Since(info.ModTime()) > maxAge
+}
+
+// columns are the indexes of the fields Load uses, -1 if absent.
+type columns struct {
+	icao24, registration, typeCode, manufacturer, model, operator, built int
+}
+
+// columnsFor maps a header row to column indexes. Names are matched without
+// case, and the first alias present wins.
+func columnsFor(header []string) columns {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, dup := index[h]; !dup {
+			index[h] = i
+		}
+	}
+	find := func(names ...string) int {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	return columns{
+		icao24:       find("icao24"),
+		registration: find("registration"),
+		typeCode:     find("typecode"),
+		manufacturer: find("manufacturername", "manufacturer", "manufacturericao"),
+		model:        find("model"),
+		operator:     find("operator", "owner"),
+		built:        find("built", "year"),
+	}
+}
+
+func (c columns) parse(rec []string) Aircraft {
+	field := func(i int) string {
+		if i < 0 || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	a := Aircraft{
+		ICAO24:       strings.ToLower(field(c.icao24)),
+		Registration: strings.ToUpper(field(c.registration)),
+		TypeCode:     strings.ToUpper(field(c.typeCode)),
+		Manufacturer: field(c.manufacturer),
+		Model:        field(c.model),
+		Operator:     field(c.operator),
+	}
+	// "built" is a year or a date such as "2016-03-01".
+	if b := field(c.built); len(b) >= 4 {
+		if y, err := strconv.Atoi(b[:4]); err == nil && y > 1900 {
+			a.Built = y
+		}
+	}
+	return a
+}
+
+// rowReader reads CSV records. encoding/csv only understands double quotes;
+// recent OpenSky dumps quote with single quotes, which are split by hand.
+type rowReader struct {
+	csv   *csv.Reader
+	lines *bufio.Reader
+}
+
+func newRowReader(r *bufio.Reader, quote byte) *rowReader {
+	if quote == '"' {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.LazyQuotes = true
+		cr.ReuseRecord = true
+		return &rowReader{csv: cr}
+	}
+	return &rowReader{lines: r}
+}
+
+func (r *rowReader) next() ([]string, error) {
+	if r.csv != nil {
+		return r.csv.Read()
+	}
+	for {
+		line, err := r.lines.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			return splitSingleQuoted(line), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// splitSingleQuoted splits a comma-separated line whose fields may be wrapped
+// in single quotes; a doubled quote inside a quoted field is a literal quote.
+func splitSingleQuoted(line string) []string {
+	var fields []string
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\'' && inQuote && i+1 < len(line) && line[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+		case c == ',' && !inQuote:
+			fields = append(fields, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(fields, b.String())
+}
@@ -0,0 +1,286 @@
+// Package airlines is the airline registry shared by the providers and the
+// UI: ICAO and IATA designators, radio callsign, name, country, alliance,
+// whether the airline still operates and whether it only flies cargo. An embedded dataset is built in and a
+// local file can add airlines or override built-in ones.
+package airlines
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed airlines.csv
+var airlinesCSV []byte
+
+// Airline is one airline in the registry.
+type Airline struct {
+	ICAO     string // three-letter designator used in callsigns, e.g. "UAL"
+	IATA     string // two-character code used in flight numbers, e.g. "UA"
+	Callsign string // radio telephony, e.g. "UNITED"
+	Name     string // e.g. "United Airlines"
+	Country  string // ISO 3166-1 alpha-2, upper case, e.g. "US"
+	Alliance string // "Star Alliance", "SkyTeam", "oneworld" or empty
+	Active   bool   // false for airlines that no longer fly
+	Cargo    bool   // true for all-cargo airlines such as FedEx
+}
+
+func (a Airline) String() string {
+	return fmt.Sprintf("%s/%s %s (%s)", a.ICAO, a.IATA, a.Name, a.Country)
+}
+
+var (
+	mu      sync.RWMutex
+	entries = make(map[string]Airline) // by key()
+
+	// Indexes over entries, rebuilt on every load.
+	byICAO map[string]Airline
+	byIATA map[string]Airline // active airlines win when a code was reassigned
+	byName map[string]Airline // by lower-case name
+)
+
+func init() {
+	n, err := load(bytes.NewReader(airlinesCSV))
+	if err != nil {
+		log.Printf("[airlines] warning: embedded dataset: %v", err)
+	}
+	log.Printf("[airlines] loaded %d airlines", n)
+}
+
+// LoadFile merges airlines from a CSV file in the embedded dataset's format
+// (icao,iata,callsign,name,country,alliance,active,cargo, with a header row;
+// active and cargo are Y or N, and the cargo column may be left out). A row replaces the airline with the same ICAO designator, or the
+// same IATA code if it has no ICAO designator, so a local file can correct an
+// entry, add a missing airline or mark one inactive. It returns the number of
+// airlines read.
+func LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("airlines: %w", err)
+	}
+	defer f.Close()
+	n, err := load(f)
+	if err != nil {
+		return n, fmt.Errorf("airlines: %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// load reads CSV rows into the registry and rebuilds the indexes.
+func load(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	if _, err := cr.Read(); err != nil { // header
+		return 0, err
+	}
+
+	var read []Airline
+	var loadErr error
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			loadErr = err
+			break
+		}
+		a, err := parseRecord(rec)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			loadErr = fmt.Errorf("line %d: %w", line, err)
+			break
+		}
+		read = append(read, a)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, a := range read {
+		entries[key(a)] = a
+	}
+	reindex()
+	return len(read), loadErr
+}
+
+func parseRecord(rec []string) (Airline, error) {
+	if len(rec) != 7 && len(rec) != 8 {
+		return Airline{}, fmt.Errorf("want 7 or 8 fields, got %d", len(rec))
+	}
+	field := func(i int) string {
+		if i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	a := Airline{
+		ICAO:     strings.ToUpper(field(0)),
+		IATA:     strings.ToUpper(field(1)),
+		Callsign: strings.ToUpper(field(2)),
+		Name:     field(3),
+		Country:  strings.ToUpper(field(4)),
+		Alliance: field(5),
+	}
+	if a.ICAO == "" && a.IATA == "" {
+		return a, fmt.Errorf("%q: no ICAO or IATA code", a.Name)
+	}
+	var err error
+	if a.Active, err = parseFlag(field(6), true); err != nil {
+		return a, fmt.Errorf("%s: active %w", a.Name, err)
+	}
+	if a.Cargo, err = parseFlag(field(7), false); err != nil {
+		return a, fmt.Errorf("%s: cargo %w", a.Name, err)
+	}
+	return a, nil
+}
+
+// parseFlag parses a Y/N column, returning def if it is empty.
+func parseFlag(v string, def bool) (bool, error) {
+	switch strings.ToUpper(v) {
+	case "":
+		return def, nil
+	case "Y", "YES", "TRUE", "1":
+		return true, nil
+	case "N", "NO", "FALSE", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be Y or N, got %q", v)
+}
+
+// key identifies an entry for overrides: the ICAO designator, or the IATA
+// code for airlines without one.
+func key(a Airline) string {
+	if a.ICAO != "" {
+		return a.ICAO
+	}
+	return "iata:" + a.IATA
+}
+
+// reindex rebuilds the lookup maps. Must be called with mu held.
+func reindex() {
+	byICAO = make(map[string]Airline, len(entries))
+	byIATA = make(map[string]Airline, len(entries))
+	byName = make(map[string]Airline, len(entries))
+	for _, a := range entries {
+		if a.ICAO != "" {
+			byICAO[a.ICAO] = a
+		}
+		if a.IATA != "" {
+			if prev, ok := byIATA[a.IATA]; !ok || (a.Active && !prev.Active) {
+				byIATA[a.IATA] = a
+			}
+		}
+		if name := strings.ToLower(a.Name); name != "" {
+			if prev, ok := byName[name]; !ok || (a.Active && !prev.Active) {
+				byName[name] = a
+			}
+		}
+	}
+}
+
+// ByICAO returns the airline with the given ICAO designator, e.g. "UAL".
+func ByICAO(code string) (Airline, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := byICAO[strings.ToUpper(strings.TrimSpace(code))]
+	return a, ok
+}
+
+// ByIATA returns the airline with the given IATA code, e.g. "UA". When a code
+// has been reassigned, the active airline is returned.
+func ByIATA(code string) (Airline, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := byIATA[strings.ToUpper(strings.TrimSpace(code))]
+	return a, ok
+}
+
+// ByName returns the airline with the given name, ignoring case.
+func ByName(name string) (Airline, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+	return a, ok
+}
+
+// Lookup returns the airline for a designator of either kind: three letters
+// are tried as ICAO and two characters as IATA.
+func Lookup(code string) (Airline, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	switch len(code) {
+	case 3:
+		return ByICAO(code)
+	case 2:
+		return ByIATA(code)
+	}
+	return Airline{}, false
+}
+
+// IsKnown reports whether an active airline matches the IATA code or the
+// operator name. Some sources report the ICAO designator as the operator,
+// so a three-letter name is also tried as one.
+func IsKnown(iata, name string) bool {
+	a, ok := match(iata, name)
+	return ok && a.Active
+}
+
+// IsPassenger reports whether an active airline that isn't all-cargo matches
+// the IATA code or the operator name, as IsKnown does.
+func IsPassenger(iata, name string) bool {
+	a, ok := match(iata, name)
+	return ok && a.Active && !a.Cargo
+}
+
+// match finds the airline for an IATA code or operator name, preferring an
+// active one.
+func match(iata, name string) (Airline, bool) {
+	var found Airline
+	var ok bool
+	try := func(a Airline, hit bool) bool {
+		if hit && (!ok || (a.Active && !found.Active)) {
+			found, ok = a, true
+		}
+		return ok && found.Active
+	}
+	if iata != "" && try(ByIATA(iata)) {
+		return found, true
+	}
+	if name != "" {
+		if try(ByName(name)) {
+			return found, true
+		}
+		if len(name) == 3 && try(ByICAO(name)) {
+			return found, true
+		}
+	}
+	return found, ok
+}
+
+// Active returns every active airline with both an ICAO and an IATA code,
+// sorted by ICAO designator.
+func Active() []Airline {
+	mu.RLock()
+	defer mu.RUnlock()
+	var out []Airline
+	for _, a := range byICAO {
+		if a.Active && a.IATA != "" {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ICAO < out[j].ICAO })
+	return out
+}
+
+// Len returns the number of airlines in the registry.
+func Len() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(entries)
+}
@@ -0,0 +1,257 @@
+// Package airports is a database of airports: codes, names, location,
+// elevation, time zone and runways. A curated set of major airports is
+// embedded; OurAirports' world dataset can be loaded over it (see
+// LoadOurAirports). It lets providers complete the airport references they
+// report and the UI show cities and flags for routes whose source only gave a
+// code.
+package airports
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed airports.csv
+var airportsCSV []byte
+
+// Airport is one airport in the database.
+type Airport struct {
+	ICAO        string   // e.g. "KSFO"
+	IATA        string   // e.g. "SFO", empty if none
+	Name        string   // e.g. "San Francisco Intl"
+	City        string   // city served, e.g. "San Francisco"
+	Country     string   // ISO 3166-1 alpha-2, upper case, e.g. "US"
+	Lat, Lon    float64  // airport reference point
+	ElevationFt int      // feet above mean sea level
+	TZ          string   // IANA time zone, e.g. "America/Los_Angeles"
+	Runways     []Runway // empty if not recorded
+}
+
+// Runway is one runway, named by both ends.
+type Runway struct {
+	Ident string // e.g. "10L/28R"
+	// Threshold coordinates of the low- and high-numbered ends; zero when the
+	// dataset doesn't record them.
+	LatLow, LonLow   float64
+	LatHigh, LonHigh float64
+}
+
+// HasEnds reports whether the runway's threshold coordinates are known.
+func (r Runway) HasEnds() bool {
+	return (r.LatLow != 0 || r.LonLow != 0) && (r.LatHigh != 0 || r.LonHigh != 0)
+}
+
+// Location returns the airport's time zone, or UTC if it is unknown.
+func (a Airport) Location() *time.Location {
+	if a.TZ == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(a.TZ)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Label returns the short display code: IATA if the airport has one, else ICAO.
+func (a Airport) Label() string {
+	if a.IATA != "" {
+		return a.IATA
+	}
+	return a.ICAO
+}
+
+func (a Airport) String() string {
+	return fmt.Sprintf("%s/%s %s (%s, %s)", a.ICAO, a.IATA, a.Name, a.City, a.Country)
+}
+
+var (
+	mu     sync.RWMutex
+	byICAO = make(map[string]Airport)
+	byIATA = make(map[string]Airport)
+)
+
+func init() {
+	n, err := load(bytes.NewReader(airportsCSV))
+	if err != nil {
+		log.Printf("[airports] warning: embedded dataset: %v", err)
+	}
+	log.Printf("[airports] loaded %d airports", n)
+}
+
+// LoadFile merges airports from a CSV file in the embedded dataset's format
+// (icao,iata,name,city,country,lat,lon,elevation_ft,tz,runways, with a header
+// row). Entries replace built-in airports with the same ICAO code. It returns
+// the number of airports read.
+func LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("airports: %w", err)
+	}
+	defer f.Close()
+	n, err := load(f)
+	if err != nil {
+		return n, fmt.Errorf("airports: %s: %w", path, err)
+	}
+	return n, nil
+}
+
+// load reads CSV rows into the indexes.
+func load(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 10
+	cr.ReuseRecord = true
+	if _, err := cr.Read(); err != nil { // header
+		return 0, err
+	}
+
+	n := 0
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		a, err := parseRecord(rec)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return n, fmt.Errorf("line %d: %w", line, err)
+		}
+		add(a)
+		n++
+	}
+}
+
+func parseRecord(rec []string) (Airport, error) {
+	a := Airport{
+		ICAO:    strings.ToUpper(strings.TrimSpace(rec[0])),
+		IATA:    strings.ToUpper(strings.TrimSpace(rec[1])),
+		Name:    strings.TrimSpace(rec[2]),
+		City:    strings.TrimSpace(rec[3]),
+		Country: strings.ToUpper(strings.TrimSpace(rec[4])),
+		TZ:      strings.TrimSpace(rec[8]),
+	}
+	for _, ident := range strings.Fields(rec[9]) {
+		a.Runways = append(a.Runways, Runway{Ident: ident})
+	}
+	if a.ICAO == "" {
+		return a, fmt.Errorf("missing ICAO code")
+	}
+	var err error
+	if a.Lat, err = strconv.ParseFloat(strings.TrimSpace(rec[5]), 64); err != nil {
+		return a, fmt.Errorf("%s: latitude: %w", a.ICAO, err)
+	}
+	if a.Lon, err = strconv.ParseFloat(strings.TrimSpace(rec[6]), 64); err != nil {
+		return a, fmt.Errorf("%s: longitude: %w", a.ICAO, err)
+	}
+	if v := strings.TrimSpace(rec[7]); v != "" {
+		if a.ElevationFt, err = strconv.Atoi(v); err != nil {
+			return a, fmt.Errorf("%s: elevation: %w", a.ICAO, err)
+		}
+	}
+	return a, nil
+}
+
+func add(a Airport) {
+	mu.Lock()
+	defer mu.Unlock()
+	byICAO[a.ICAO] = a
+	if a.IATA != "" {
+		byIATA[a.IATA] = a
+	}
+}
+
+// merge adds a, keeping what an existing entry for the same airport already
+// has, such as the embedded dataset's short names and time zones, and filling
+// in what it lacks. Runways with threshold coordinates replace those without.
+func merge(a Airport) {
+	mu.Lock()
+	defer mu.Unlock()
+	if old, ok := byICAO[a.ICAO]; ok {
+		fill := func(dst *string, v string) {
+			if *dst == "" {
+				*dst = v
+			}
+		}
+		merged := old
+		fill(&merged.IATA, a.IATA)
+		fill(&merged.Name, a.Name)
+		fill(&merged.City, a.City)
+		fill(&merged.Country, a.Country)
+		fill(&merged.TZ, a.TZ)
+		if merged.ElevationFt == 0 {
+			merged.ElevationFt = a.ElevationFt
+		}
+		if len(a.Runways) > 0 && (len(merged.Runways) == 0 || a.Runways[0].HasEnds()) {
+			merged.Runways = a.Runways
+		}
+		a = merged
+	}
+	byICAO[a.ICAO] = a
+	if a.IATA != "" {
+		byIATA[a.IATA] = a
+	}
+}
+
+// ByICAO returns the airport with the given ICAO code.
+func ByICAO(code string) (Airport, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := byICAO[strings.ToUpper(strings.TrimSpace(code))]
+	return a, ok
+}
+
+// ByIATA returns the airport with the given IATA code.
+func ByIATA(code string) (Airport, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := byIATA[strings.ToUpper(strings.TrimSpace(code))]
+	return a, ok
+}
+
+// Lookup returns the airport for an ICAO or IATA code. Four-letter codes are
+// tried as ICAO first and three-letter codes as IATA first.
+func Lookup(code string) (Airport, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) == 3 {
+		if a, ok := ByIATA(code); ok {
+			return a, true
+		}
+	}
+	return ByICAO(code)
+}
+
+// Len returns the number of airports in the database.
+func Len() int {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(byICAO)
+}
+
+// Country returns the ISO 3166-1 alpha-2 country code (lower case, as flag
+// URLs use) for an airport code. Airports missing from the database are
+// guessed from the ICAO prefix; it returns "" if neither knows.
+func Country(code string) string {
+	if a, ok := Lookup(code); ok && a.Country != "" {
+		return strings.ToLower(a.Country)
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 4 {
+		return ""
+	}
+	if cc, ok := icaoPrefixToCountry[code[:2]]; ok {
+		return cc
+	}
+	return icaoPrefixToCountry[code[:1]]
+}
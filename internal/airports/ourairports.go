@@ -0,0 +1,263 @@
+package airports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OurAirportsURL is where OurAirports publishes its public-domain world
+// airport database, refreshed nightly.
+const OurAirportsURL = "https://davidmegginson.github.io/ourairports-data/"
+
+// The OurAirports export files LoadOurAirports reads.
+const (
+	airportsFile = "airports.csv"
+	runwaysFile  = "runways.csv"
+)
+
+// DefaultDir is where the OurAirports export is kept unless configured
+// otherwise.
+func DefaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".flighttracker", "airports")
+}
+
+// Stale reports whether the export in dir is missing or older than maxAge.
+func Stale(dir string, maxAge time.Duration) bool {
+	info, err := os.Stat(filepath.Join(dir, airportsFile))
+	return err != nil || time.Since(info.ModTime()) > maxAge
+}
+
+// Update downloads the OurAirports export from baseURL into dir. Each file
+// is replaced only once its download completes.
+func Update(ctx context.Context, baseURL, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("airports: %w", err)
+	}
+	for _, name := range []string{airportsFile, runwaysFile} {
+		if err := download(ctx, strings.TrimSuffix(baseURL, "/")+"/"+name, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func download(ctx context.Context, url, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("airports: creating request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("airports: download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("airports: %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".airports-*.csv")
+	if err != nil {
+		return fmt.Errorf("airports: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("airports: download failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("airports: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("airports: %w", err)
+	}
+	return nil
+}
+
+// LoadOurAirports merges the OurAirports export in dir into the database:
+// airports.csv, and runways.csv if it is there. Airports with an ICAO code
+// that are large or medium, or have scheduled service, are kept; heliports,
+// airstrips and closed fields are not. OurAirports has no time zones, so an
+// airport only has one if the embedded dataset does. It returns the number
+// of airports read.
+func LoadOurAirports(dir string) (int, error) {
+	read, err := readOurAirports(filepath.Join(dir, airportsFile))
+	if err != nil {
+		return 0, err
+	}
+	if err := readOurRunways(filepath.Join(dir, runwaysFile), read); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	for _, a := range read {
+		merge(*a)
+	}
+	return len(read), nil
+}
+
+// readOurAirports reads the airports kept from airports.csv, by OurAirports
+// ident.
+func readOurAirports(path string) (map[string]*Airport, error) {
+	rows, err := openCSV(path)
+	if err != nil {
+		return nil, fmt.Errorf("airports: %w", err)
+	}
+	defer rows.Close()
+
+	read := make(map[string]*Airport)
+	for {
+		rec, err := rows.next()
+		if err == io.EOF {
+			return read, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch kind := rows.field(rec, "type"); {
+		case kind == "closed" || kind == "heliport":
+			continue
+		case kind != "large_airport" && kind != "medium_airport" && rows.field(rec, "scheduled_service") != "yes":
+			continue
+		}
+		icao := ""
+		for _, col := range []string{"icao_code", "gps_code", "ident"} {
+			if code := strings.ToUpper(rows.field(rec, col)); isICAOCode(code) {
+				icao = code
+				break
+			}
+		}
+		if icao == "" {
+			continue
+		}
+		a := &Airport{
+			ICAO:    icao,
+			IATA:    strings.ToUpper(rows.field(rec, "iata_code")),
+			Name:    rows.field(rec, "name"),
+			City:    rows.field(rec, "municipality"),
+			Country: strings.ToUpper(rows.field(rec, "iso_country")),
+		}
+		if a.Lat, err = strconv.ParseFloat(rows.field(rec, "latitude_deg"), 64); err != nil {
+			return nil, rows.errorf("%s: latitude: %w", icao, err)
+		}
+		if a.Lon, err = strconv.ParseFloat(rows.field(rec, "longitude_deg"), 64); err != nil {
+			return nil, rows.errorf("%s: longitude: %w", icao, err)
+		}
+		if v := rows.field(rec, "elevation_ft"); v != "" {
+			if a.ElevationFt, err = strconv.Atoi(v); err != nil {
+				return nil, rows.errorf("%s: elevation: %w", icao, err)
+			}
+		}
+		read[rows.field(rec, "ident")] = a
+	}
+}
+
+// readOurRunways adds the open runways in runways.csv to the airports read.
+func readOurRunways(path string, read map[string]*Airport) error {
+	rows, err := openCSV(path)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for {
+		rec, err := rows.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a, ok := read[rows.field(rec, "airport_ident")]
+		if !ok || rows.field(rec, "closed") == "1" {
+			continue
+		}
+		low, high := rows.field(rec, "le_ident"), rows.field(rec, "he_ident")
+		r := Runway{Ident: low}
+		if high != "" {
+			r.Ident += "/" + high
+		}
+		// Threshold coordinates are often missing for smaller fields.
+		r.LatLow, _ = strconv.ParseFloat(rows.field(rec, "le_latitude_deg"), 64)
+		r.LonLow, _ = strconv.ParseFloat(rows.field(rec, "le_longitude_deg"), 64)
+		r.LatHigh, _ = strconv.ParseFloat(rows.field(rec, "he_latitude_deg"), 64)
+		r.LonHigh, _ = strconv.ParseFloat(rows.field(rec, "he_longitude_deg"), 64)
+		if r.Ident != "" {
+			a.Runways = append(a.Runways, r)
+		}
+	}
+}
+
+// isICAOCode reports whether code looks like an ICAO airport code: four
+// letters. Local codes such as "K0Q9" or "US-0001" are not.
+func isICAOCode(code string) bool {
+	if len(code) != 4 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// csvRows reads a CSV file whose columns are found by header name.
+type csvRows struct {
+	f    *os.File
+	r    *csv.Reader
+	path string
+	cols map[string]int
+}
+
+func openCSV(path string) (*csvRows, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("airports: %s: reading header: %w", path, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	return &csvRows{f: f, r: r, path: path, cols: cols}, nil
+}
+
+func (c *csvRows) Close() error { return c.f.Close() }
+
+func (c *csvRows) next() ([]string, error) {
+	rec, err := c.r.Read()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("airports: %s: %w", c.path, err)
+	}
+	return rec, err
+}
+
+// field returns the named column of rec, or "" if the file has no such column.
+func (c *csvRows) field(rec []string, name string) string {
+	i, ok := c.cols[name]
+	if !ok || i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+func (c *csvRows) errorf(format string, args ...any) error {
+	line, _ := c.r.FieldPos(0)
+	return fmt.Errorf("airports: %s: line %d: %w", c.path, line, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,145 @@
+package airports
+
+// icaoPrefixToCountry maps ICAO airport code prefixes to ISO 3166-1 alpha-2
+// country codes, for airports missing from the database.
+var icaoPrefixToCountry = map[string]string{
+	// North America
+	"K":  "us", // USA (continental)
+	"PH": "us", // Hawaii
+	"PA": "us", // Alaska
+	"PG": "us", // Guam
+	"C":  "ca", // Canada
+	"MM": "mx", // Mexico
+
+	// Central America & Caribbean
+	"MG": "gt", // Guatemala
+	"MH": "hn", // Honduras
+	"MN": "ni", // Nicaragua
+	"MR": "cr", // Costa Rica
+	"MP": "pa", // Panama
+	"MK": "jm", // Jamaica
+	"MT": "ht", // Haiti
+	"MD": "do", // Dominican Republic
+	"MU": "cu", // Cuba
+	"TB": "bb", // Barbados
+	"TT": "tt", // Trinidad
+	"TJ": "pr", // Puerto Rico
+	"TI": "vi", // U.S. Virgin Islands
+
+	// South America
+	"SB": "br", // Brazil
+	"SA": "ar", // Argentina
+	"SC": "cl", // Chile
+	"SK": "co", // Colombia
+	"SP": "pe", // Peru
+	"SV": "ve", // Venezuela
+	"SE": "ec", // Ecuador
+	"SU": "uy", // Uruguay
+	"SG": "py", // Paraguay
+	"SL": "bo", // Bolivia
+
+	// Europe
+	"EG": "gb", // United Kingdom
+	"EI": "ie", // Ireland
+	"LF": "fr", // France
+	"ED": "de", // Germany
+	"LI": "it", // Italy
+	"LE": "es", // Spain
+	"LP": "pt", // Portugal
+	"EH": "nl", // Netherlands
+	"EB": "be", // Belgium
+	"LS": "ch", // Switzerland
+	"LO": "at", // Austria
+	"EK": "dk", // Denmark
+	"EN": "no", // Norway
+	"ES": "se", // Sweden
+	"EF": "fi", // Finland
+	"EE": "ee", // Estonia
+	"EV": "lv", // Latvia
+	"EY": "lt", // Lithuania
+	"EP": "pl", // Poland
+	"LK": "cz", // Czech Republic
+	"LZ": "sk", // Slovakia
+	"LH": "hu", // Hungary
+	"LR": "ro", // Romania
+	"LB": "bg", // Bulgaria
+	"LG": "gr", // Greece
+	"LT": "tr", // Turkey
+	"LJ": "si", // Slovenia
+	"LD": "hr", // Croatia
+	"LY": "rs", // Serbia
+	"BI": "is", // Iceland
+	"LU": "md", // Moldova
+	"UK": "ua", // Ukraine
+
+	// Middle East
+	"OE": "sa", // Saudi Arabia
+	"OM": "ae", // UAE
+	"OB": "bh", // Bahrain
+	"OK": "kw", // Kuwait
+	"OO": "om", // Oman
+	"OT": "qa", // Qatar
+	"OI": "ir", // Iran
+	"OJ": "jo", // Jordan
+	"OL": "lb", // Lebanon
+	"OS": "sy", // Syria
+	"LL": "il", // Israel
+
+	// Asia
+	"ZS": "cn", // China (south)
+	"ZB": "cn", // China (north)
+	"ZG": "cn", // China (central)
+	"ZU": "cn", // China (west)
+	"ZW": "cn", // China
+	"ZH": "cn", // China
+	"RJ": "jp", // Japan
+	"RK": "kr", // South Korea
+	"VT": "th", // Thailand
+	"WS": "sg", // Singapore
+	"WM": "my", // Malaysia
+	"WI": "id", // Indonesia
+	"WA": "id", // Indonesia
+	"RP": "ph", // Philippines
+	"VV": "vn", // Vietnam
+	"VH": "hk", // Hong Kong
+	"VM": "mo", // Macau
+	"RC": "tw", // Taiwan
+	"VI": "in", // India (north)
+	"VO": "in", // India (south)
+	"VA": "in", // India (west)
+	"VE": "in", // India (east)
+	"VQ": "bt", // Bhutan
+	"VN": "np", // Nepal
+	"VL": "la", // Laos
+	"VY": "mm", // Myanmar
+	"VC": "lk", // Sri Lanka
+	"OP": "pk", // Pakistan
+
+	// Oceania
+	"Y":  "au", // Australia
+	"NZ": "nz", // New Zealand
+	"NF": "fj", // Fiji
+	"PF": "us", // Midway
+	"PT": "fm", // Micronesia
+
+	// Africa
+	"DA": "dz", // Algeria
+	"DT": "tn", // Tunisia
+	"GM": "ma", // Morocco
+	"HA": "et", // Ethiopia
+	"HK": "ke", // Kenya
+	"HT": "tz", // Tanzania
+	"HR": "rw", // Rwanda
+	"HU": "ug", // Uganda
+	"FA": "za", // South Africa
+	"FV": "zw", // Zimbabwe
+	"DN": "ng", // Nigeria
+	"DG": "gh", // Ghana
+	"FW": "mw", // Malawi
+	"FL": "zm", // Zambia
+	"HE": "eg", // Egypt
+	"HC": "so", // Somalia
+
+	// Russia
+	"U": "ru", // Russia
+}
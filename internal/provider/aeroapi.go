@@ -1,9 +1,11 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -16,6 +18,7 @@ const aeroAPIBaseURL = "https://aeroapi.flightaware.com/aeroapi"
 type AeroAPIProvider struct {
 	apiKey     string
 	httpClient *http.Client
+	budget     *aeroBudget // nil = no dollar cap
 }
 
 // NewAeroAPIProvider creates a new AeroAPI provider.
@@ -30,13 +33,62 @@ func NewAeroAPIProvider(apiKey string) *AeroAPIProvider {
 
 func (a *AeroAPIProvider) Name() string { return "aeroapi" }
 
-func (a *AeroAPIProvider) doRequest(path string, params url.Values, dest any) error {
+// SetMonthlyBudget caps AeroAPI spend at usd per calendar month (UTC). Spend
+// is estimated per request and reconciled with /account/usage every ten
+// minutes; discovery polling slows as spend nears the cap and stops shortly
+// before it, leaving the remainder for featured-flight position polls. The
+// cap is enforced by MultiProvider, before a request uses a rate slot.
+func (a *AeroAPIProvider) SetMonthlyBudget(usd float64) {
+	a.budget = newAeroBudget(usd)
+	log.Printf("[aeroapi] monthly budget: $%.2f", usd)
+}
+
+// checkSpend returns an error if the budget doesn't allow a request of class
+// p. Featured requests are position polls; everything else is billed and
+// throttled as discovery.
+func (a *AeroAPIProvider) checkSpend(ctx context.Context, p Priority) error {
+	kind := aeroCallDiscovery
+	if p == PriorityFeatured {
+		kind = aeroCallPosition
+	}
+	return a.checkBudget(ctx, kind)
+}
+
+// checkBudget returns an error if the budget doesn't allow a call of this
+// kind, first refreshing spend from the account if it is due.
+func (a *AeroAPIProvider) checkBudget(ctx context.Context, kind aeroCallKind) error {
+	if a.budget == nil {
+		return nil
+	}
+	if a.budget.syncDue() {
+		if err := a.syncUsage(ctx); err != nil {
+			log.Printf("[aeroapi] usage check failed, using local estimate: %v", err)
+		}
+	}
+	return a.budget.allow(kind)
+}
+
+// syncUsage fetches this month's spend from /account/usage.
+func (a *AeroAPIProvider) syncUsage(ctx context.Context) error {
+	params := url.Values{
+		"start": {monthStart(time.Now()).Format("2006-01-02")},
+	}
+	var usage aeroUsage
+	if err := a.doRequest(ctx, "/account/usage", params, &usage); err != nil {
+		return err
+	}
+	a.budget.sync(usage.TotalCost)
+	log.Printf("[aeroapi] spend this month: %v", a.budget)
+	return nil
+}
+
+func (a *AeroAPIProvider) doRequest(ctx context.Context, path string, params url.Values, dest any) error {
 	u := aeroAPIBaseURL + path
 	if len(params) > 0 {
 		u += "?" + params.Encode()
 	}
 
-	req, err := http.NewRequest(http.MethodGet, u, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return fmt.Errorf("aeroapi: creating request: %w", err)
 	}
@@ -49,11 +101,18 @@ func (a *AeroAPIProvider) doRequest(path string, params url.Values, dest any) er
 	}
 	defer resp.Body.Close()
 
+	if err := checkQuota(ctx, a.Name(), resp); err != nil {
+		return err
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("aeroapi: HTTP %d: %s", resp.StatusCode, string(body))
 	}
 
+	if a.budget != nil {
+		a.budget.charge(path)
+	}
+
 	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
 		return fmt.Errorf("aeroapi: decoding response: %w", err)
 	}
@@ -61,7 +120,7 @@ func (a *AeroAPIProvider) doRequest(path string, params url.Values, dest any) er
 }
 
 // GetFlightsNear returns en-route flights near the given airport.
-func (a *AeroAPIProvider) GetFlightsNear(airportICAO string, direction FlightDirection) ([]Flight, error) {
+func (a *AeroAPIProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
 	params := url.Values{
 		"type":      {"Airline"},
 		"max_pages": {"1"},
@@ -78,7 +137,7 @@ func (a *AeroAPIProvider) GetFlightsNear(airportICAO string, direction FlightDir
 		Arrivals   []aeroFlight `json:"arrivals"`
 		Departures []aeroFlight `json:"departures"`
 	}
-	if err := a.doRequest(endpoint, params, &raw); err != nil {
+	if err := a.doRequest(ctx, endpoint, params, &raw); err != nil {
 		return nil, err
 	}
 
@@ -98,41 +157,92 @@ func (a *AeroAPIProvider) GetFlightsNear(airportICAO string, direction FlightDir
 	return result, nil
 }
 
-// GetFlightInfo fetches full flight info by IATA ident (e.g. "NH105") to get
-// route data (origin/destination). Returns nil if not found.
-func (a *AeroAPIProvider) GetFlightInfo(identIATA string) *Flight {
+// GetRoute returns the route of the en-route flight with the flight's
+// callsign, from /flights/{ident}.
+func (a *AeroAPIProvider) GetRoute(ctx context.Context, flight *Flight) (*Route, error) {
+	ident := callsignOf(flight)
+	if ident == "" {
+		ident = flight.IdentIATA
+	}
+	if ident == "" {
+		return nil, fmt.Errorf("aeroapi: no callsign: %w", ErrNotFound)
+	}
+	f, err := a.enRoute(ctx, ident)
+	if err != nil {
+		return nil, err
+	}
+	if f.Origin == nil && f.Destination == nil {
+		return nil, fmt.Errorf("aeroapi: %q has no route: %w", ident, ErrNotFound)
+	}
+	return &Route{Origin: f.Origin, Destination: f.Destination}, nil
+}
+
+// enRoute returns the flight with the given ident that is currently en route.
+func (a *AeroAPIProvider) enRoute(ctx context.Context, ident string) (*Flight, error) {
 	var raw struct {
 		Flights []aeroFlight `json:"flights"`
 	}
-	if err := a.doRequest("/flights/"+url.PathEscape(identIATA), nil, &raw); err != nil {
-		return nil
+	if err := a.doRequest(ctx, "/flights/"+url.PathEscape(ident), nil, &raw); err != nil {
+		return nil, err
 	}
 	// Find the first active (en route) flight
 	for _, f := range raw.Flights {
 		if f.ActualOff != nil && f.ActualOn == nil && !f.Cancelled {
 			flight := f.toFlight()
-			return &flight
+			return &flight, nil
 		}
 	}
-	return nil
+	return nil, fmt.Errorf("aeroapi: %q not en route: %w", ident, ErrNotFound)
 }
 
 // GetFlightPosition returns the latest position for a flight.
-func (a *AeroAPIProvider) GetFlightPosition(flight *Flight) (*FlightPosition, error) {
+func (a *AeroAPIProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
 	var raw struct {
 		LastPosition *aeroPosition `json:"last_position"`
 		ActualOn     *time.Time    `json:"actual_on"`
 	}
-	if err := a.doRequest("/flights/"+url.PathEscape(flight.FlightID)+"/position", nil, &raw); err != nil {
+	if err := a.doRequest(ctx, "/flights/"+url.PathEscape(flight.FlightID)+"/position", nil, &raw); err != nil {
 		return nil, err
 	}
 	if raw.LastPosition == nil {
-		return nil, fmt.Errorf("aeroapi: no position data")
+		return nil, fmt.Errorf("aeroapi: no position data: %w", ErrNotFound)
 	}
 	pos := raw.LastPosition.toPosition()
 	return &pos, nil
 }
 
+// GetPositionsNear returns every flight AeroAPI is tracking in the ~60nm box
+// around the airport, with positions, from one /flights/search query. It is
+// billed and throttled as discovery.
+func (a *AeroAPIProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	lat, lon := airportCoords(airportICAO)
+	box := boxAround(lat, lon, 1.0)
+	params := url.Values{
+		"query":     {fmt.Sprintf(`-latlong "%.4f %.4f %.4f %.4f"`, box.LatMin, box.LonMin, box.LatMax, box.LonMax)},
+		"max_pages": {"1"},
+	}
+
+	var raw struct {
+		Flights []struct {
+			aeroFlight
+			LastPosition *aeroPosition `json:"last_position"`
+		} `json:"flights"`
+	}
+	if err := a.doRequest(ctx, "/flights/search", params, &raw); err != nil {
+		return nil, err
+	}
+
+	snap := &AreaSnapshot{Box: box, FetchedAt: time.Now()}
+	for _, f := range raw.Flights {
+		if f.LastPosition == nil {
+			continue
+		}
+		snap.Flights = append(snap.Flights, f.toFlight())
+		snap.Positions = append(snap.Positions, f.LastPosition.toPosition())
+	}
+	return snap, nil
+}
+
 // ── AeroAPI JSON types ──
 
 type aeroAirportRef struct {
@@ -163,6 +273,7 @@ func (a *aeroAirportRef) toAirportRef() *AirportRef {
 	if a.City != nil {
 		ref.City = *a.City
 	}
+	completeAirport(ref)
 	return ref
 }
 
@@ -179,6 +290,7 @@ type aeroFlight struct {
 	Destination  *aeroAirportRef `json:"destination"`
 	Status       string          `json:"status"`
 	AircraftType *string         `json:"aircraft_type"`
+	Registration *string         `json:"registration"`
 	FlightType   string          `json:"type"`
 	Cancelled    bool            `json:"cancelled"`
 	ActualOff    *time.Time      `json:"actual_off"`
@@ -215,21 +327,44 @@ func (f *aeroFlight) toFlight() Flight {
 	if f.AircraftType != nil {
 		flight.AircraftType = *f.AircraftType
 	}
+	if f.Registration != nil {
+		flight.Registration = *f.Registration
+	}
 	return flight
 }
 
+// aeroUsage is the /account/usage response (only the fields we use).
+type aeroUsage struct {
+	TotalCalls int     `json:"total_calls"`
+	TotalCost  float64 `json:"total_cost"` // USD, before volume discounts
+}
+
 type aeroPosition struct {
-	Altitude       int       `json:"altitude"`
+	Altitude       int       `json:"altitude"` // hundreds of feet
 	AltitudeChange string    `json:"altitude_change"`
 	Groundspeed    int       `json:"groundspeed"`
 	Heading        *int      `json:"heading"`
 	Latitude       float64   `json:"latitude"`
 	Longitude      float64   `json:"longitude"`
 	Timestamp      time.Time `json:"timestamp"`
+	UpdateType     *string   `json:"update_type"`
+}
+
+// aeroUpdateTypes maps AeroAPI's update_type to a position source.
+var aeroUpdateTypes = map[string]PositionSource{
+	"A": SourceADSB,
+	"M": SourceMLAT,
+	"Z": SourceRadar,
+	"X": SourceRadar, // ASDE-X surface radar
+	"S": SourceSatellite,
+	"D": SourceSatellite, // datalink, e.g. ADS-C
+	"O": SourceEstimated, // oceanic position reports
+	"P": SourceEstimated, // projected
 }
 
 func (p *aeroPosition) toPosition() FlightPosition {
-	// Normalize altitude_change: AeroAPI may return full words or single chars
+	// Normalize altitude_change: AeroAPI may return full words or single chars.
+	// It reports no numeric vertical rate, so the trend is all there is.
 	altChange := p.AltitudeChange
 	switch strings.ToLower(altChange) {
 	case "climbing", "c":
@@ -240,13 +375,21 @@ func (p *aeroPosition) toPosition() FlightPosition {
 		altChange = "-"
 	}
 
-	return FlightPosition{
-		Altitude:       p.Altitude,
+	pos := FlightPosition{
+		BaroAltitude:   Feet(p.Altitude * 100),
 		AltitudeChange: altChange,
-		Groundspeed:    p.Groundspeed,
+		Groundspeed:    Knots(p.Groundspeed),
 		Heading:        p.Heading,
 		Latitude:       p.Latitude,
 		Longitude:      p.Longitude,
 		Timestamp:      p.Timestamp,
+		LastContact:    p.Timestamp,
+	}
+	if pos.Timestamp.IsZero() {
+		pos.Timestamp = time.Now()
+	}
+	if p.UpdateType != nil {
+		pos.Source = aeroUpdateTypes[strings.ToUpper(*p.UpdateType)]
 	}
+	return pos
 }
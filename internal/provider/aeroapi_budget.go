@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// aeroUsageSyncInterval matches how often AeroAPI refreshes /account/usage.
+	aeroUsageSyncInterval = 10 * time.Minute
+
+	// Spend thresholds, as a fraction of the monthly cap. Between slowAt and
+	// stopDiscoveryAt discovery polls are spaced out progressively; above
+	// stopDiscoveryAt only position polls are made, and at the cap nothing is.
+	aeroBudgetSlowAt          = 0.75
+	aeroBudgetStopDiscoveryAt = 0.95
+	// aeroBudgetMaxSpacing is the discovery interval just below stopDiscoveryAt.
+	aeroBudgetMaxSpacing = 5 * time.Minute
+
+	// aeroDefaultCost is charged for endpoints missing from the cost table.
+	aeroDefaultCost = 0.01
+)
+
+// aeroAPICosts is the per-page list price in USD of each endpoint we call.
+// Keys are path patterns; see aeroEndpoint. Check these against your plan.
+var aeroAPICosts = map[string]float64{
+	"/airports/{id}/flights/arrivals":   0.005,
+	"/airports/{id}/flights/departures": 0.005,
+	"/flights/{ident}":                  0.005,
+	"/flights/{id}/position":            0.010,
+	"/flights/search":                   0.010,
+	"/account/usage":                    0,
+}
+
+// aeroEndpoint maps a request path to its aeroAPICosts pattern.
+func aeroEndpoint(path string) string {
+	switch {
+	case path == "/account/usage", path == "/flights/search":
+		return path
+	case strings.HasPrefix(path, "/airports/") && strings.HasSuffix(path, "/flights/arrivals"):
+		return "/airports/{id}/flights/arrivals"
+	case strings.HasPrefix(path, "/airports/") && strings.HasSuffix(path, "/flights/departures"):
+		return "/airports/{id}/flights/departures"
+	case strings.HasPrefix(path, "/flights/") && strings.HasSuffix(path, "/position"):
+		return "/flights/{id}/position"
+	case strings.HasPrefix(path, "/flights/") && strings.Count(path, "/") == 2:
+		return "/flights/{ident}"
+	}
+	return path
+}
+
+// aeroEndpointCost returns the price of one page from the endpoint at path.
+func aeroEndpointCost(path string) float64 {
+	if cost, ok := aeroAPICosts[aeroEndpoint(path)]; ok {
+		return cost
+	}
+	return aeroDefaultCost
+}
+
+// aeroCallKind distinguishes discovery polling, which is throttled first as
+// spend approaches the cap, from position polls for the featured flight.
+type aeroCallKind int
+
+const (
+	aeroCallDiscovery aeroCallKind = iota
+	aeroCallPosition
+)
+
+// aeroBudget tracks AeroAPI spend for the current calendar month (UTC)
+// against a dollar cap. Spend is estimated locally from the cost table and
+// periodically corrected from /account/usage.
+type aeroBudget struct {
+	mu            sync.Mutex
+	capUSD        float64
+	spentUSD      float64
+	month         time.Time // start of the month spentUSD covers
+	lastSync      time.Time // last /account/usage attempt
+	lastDiscovery time.Time
+}
+
+func newAeroBudget(capUSD float64) *aeroBudget {
+	return &aeroBudget{capUSD: capUSD, month: monthStart(time.Now())}
+}
+
+// monthStart returns midnight UTC on the first of t's month.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rollover resets spend when a new month starts. Must be called with mu held.
+func (b *aeroBudget) rollover() {
+	if m := monthStart(time.Now()); m.After(b.month) {
+		b.month = m
+		b.spentUSD = 0
+		b.lastSync = time.Time{}
+	}
+}
+
+// syncDue reports whether /account/usage should be checked, and marks the
+// attempt so concurrent callers don't all sync.
+func (b *aeroBudget) syncDue() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+	if time.Since(b.lastSync) < aeroUsageSyncInterval {
+		return false
+	}
+	b.lastSync = time.Now()
+	return true
+}
+
+// sync replaces the local estimate with the account's reported spend. The
+// usage data lags by up to ten minutes, so a higher local estimate is kept.
+func (b *aeroBudget) sync(totalUSD float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if totalUSD > b.spentUSD {
+		b.spentUSD = totalUSD
+	}
+}
+
+// charge adds the cost of a successful request to path.
+func (b *aeroBudget) charge(path string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+	b.spentUSD += aeroEndpointCost(path)
+}
+
+// allow returns an error if a call of the given kind should not be made now.
+func (b *aeroBudget) allow(kind aeroCallKind) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rollover()
+
+	used := b.spentUSD / b.capUSD
+	untilReset := time.Until(monthStart(time.Now()).AddDate(0, 1, 0))
+
+	switch {
+	case used >= 1:
+		return &RateLimitedError{Provider: "aeroapi", Reason: "monthly budget", RetryAfter: untilReset}
+	case kind == aeroCallPosition:
+		return nil
+	case used >= aeroBudgetStopDiscoveryAt:
+		return &RateLimitedError{Provider: "aeroapi", Reason: "monthly budget", RetryAfter: untilReset}
+	case used >= aeroBudgetSlowAt:
+		// Space discovery out linearly from no delay to aeroBudgetMaxSpacing.
+		frac := (used - aeroBudgetSlowAt) / (aeroBudgetStopDiscoveryAt - aeroBudgetSlowAt)
+		spacing := time.Duration(frac * float64(aeroBudgetMaxSpacing))
+		if wait := spacing - time.Since(b.lastDiscovery); wait > 0 {
+			return &RateLimitedError{Provider: "aeroapi", Reason: "monthly budget", RetryAfter: wait}
+		}
+	}
+	b.lastDiscovery = time.Now()
+	return nil
+}
+
+// String summarises spend, e.g. "$12.34 of $50.00 (25%)".
+func (b *aeroBudget) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fmt.Sprintf("$%.2f of $%.2f (%.0f%%)", b.spentUSD, b.capUSD, b.spentUSD/b.capUSD*100)
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// BoundingBox is a lat/lon rectangle in degrees.
+type BoundingBox struct {
+	LatMin, LonMin, LatMax, LonMax float64
+}
+
+// boxAround returns the box extending delta degrees each way from lat/lon.
+func boxAround(lat, lon, delta float64) BoundingBox {
+	return BoundingBox{LatMin: lat - delta, LonMin: lon - delta, LatMax: lat + delta, LonMax: lon + delta}
+}
+
+// boxAroundNM returns the box extending nm nautical miles each way from
+// lat/lon. A degree of longitude shrinks with cos(lat), so the box is wider
+// in degrees than it is tall, up to the whole globe near the poles.
+func boxAroundNM(lat, lon, nm float64) BoundingBox {
+	dLat := nm / 60
+	dLon := math.Min(dLat/math.Cos(lat*math.Pi/180), 180)
+	return BoundingBox{LatMin: lat - dLat, LonMin: lon - dLon, LatMax: lat + dLat, LonMax: lon + dLon}
+}
+
+// Contains reports whether lat/lon lies inside the box. Boxes built around a
+// point near the antimeridian extend past ±180°, so lon is also tried a turn
+// either way.
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.LatMin || lat > b.LatMax {
+		return false
+	}
+	for _, l := range []float64{lon, lon - 360, lon + 360} {
+		if l >= b.LonMin && l <= b.LonMax {
+			return true
+		}
+	}
+	return false
+}
+
+func (b BoundingBox) String() string {
+	return fmt.Sprintf("%.2f,%.2f..%.2f,%.2f", b.LatMin, b.LonMin, b.LatMax, b.LonMax)
+}
+
+// AreaSnapshot is every aircraft a provider reported inside a bounding box in
+// one request. Snapshots may be shared by a cache, so treat them as read-only.
+type AreaSnapshot struct {
+	Box       BoundingBox
+	Flights   []Flight         // every aircraft in the box, airborne or not
+	Positions []FlightPosition // Positions[i] is where Flights[i] was
+	FetchedAt time.Time
+}
+
+// Airborne returns the snapshot's airborne flights that have a callsign, which
+// is what GetFlightsNear reports for an area provider.
+func (s *AreaSnapshot) Airborne() []Flight {
+	var flights []Flight
+	for _, f := range s.Flights {
+		if f.IsAirborne && f.Ident != "" {
+			flights = append(flights, f)
+		}
+	}
+	return flights
+}
+
+// Find returns the position of flight in the snapshot, matched by ICAO24
+// address or else by callsign.
+func (s *AreaSnapshot) Find(flight *Flight) (*FlightPosition, bool) {
+	if hex := flight.TransponderHex(); hex != "" {
+		for i := range s.Flights {
+			if s.Flights[i].TransponderHex() == hex {
+				pos := s.Positions[i]
+				return &pos, true
+			}
+		}
+	}
+	callsign := flight.Ident
+	if callsign == "" {
+		callsign = flight.IdentICAO
+	}
+	if callsign == "" {
+		return nil, false
+	}
+	for i, f := range s.Flights {
+		if strings.EqualFold(f.Ident, callsign) || strings.EqualFold(f.IdentICAO, callsign) {
+			pos := s.Positions[i]
+			return &pos, true
+		}
+	}
+	return nil, false
+}
+
+// AreaProvider is implemented by providers that can return every aircraft in
+// a bounding box in a single request, such as OpenSky's states/all. Their
+// GetFlightsNear is the airborne part of the snapshot of NearBox.
+type AreaProvider interface {
+	FlightProvider
+
+	// NearBox returns the box GetFlightsNear searches around airportICAO.
+	NearBox(airportICAO string) BoundingBox
+
+	// GetArea returns a snapshot of all aircraft inside box.
+	GetArea(ctx context.Context, box BoundingBox) (*AreaSnapshot, error)
+}
+
+// BulkPositionProvider is implemented by providers that can report the
+// position of every aircraft near an airport in one request, so the radar can
+// show all traffic without a position lookup per flight.
+type BulkPositionProvider interface {
+	GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error)
+}
+
+// errNoBulkPositions is returned by wrappers whose inner provider has no bulk
+// position query.
+var errNoBulkPositions = fmt.Errorf("bulk positions: %w", errors.ErrUnsupported)
+
+// Wrapper is implemented by providers that decorate another, such as
+// CachingProvider and RecordingProvider. They have every optional method but
+// only support it when the provider they wrap does.
+type Wrapper interface {
+	Unwrap() FlightProvider
+}
+
+// bulkPositions returns p as a BulkPositionProvider if it can answer bulk
+// position queries.
+func bulkPositions(p FlightProvider) (BulkPositionProvider, bool) {
+	if w, ok := p.(Wrapper); ok {
+		if _, ok := bulkPositions(w.Unwrap()); !ok {
+			return nil, false
+		}
+	}
+	bp, ok := p.(BulkPositionProvider)
+	return bp, ok
+}
+
+// positionCache is implemented by providers that keep recent bulk results.
+type positionCache interface {
+	// cachedPositionsNear returns the snapshot GetPositionsNear would return
+	// without making a request, if there is one.
+	cachedPositionsNear(airportICAO string) (*AreaSnapshot, bool)
+}
+
+// cachedPositionsNear returns a bulk snapshot p or a provider it wraps has
+// cached, without making a request.
+func cachedPositionsNear(p FlightProvider, airportICAO string) (*AreaSnapshot, bool) {
+	if pc, ok := unwrapAs[positionCache](p); ok {
+		return pc.cachedPositionsNear(airportICAO)
+	}
+	return nil, false
+}
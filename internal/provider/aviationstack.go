@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -30,7 +31,7 @@ func NewAviationStackProvider(apiKey string) *AviationStackProvider {
 func (a *AviationStackProvider) Name() string { return "aviationstack" }
 
 // GetFlightsNear returns flights for the given airport.
-func (a *AviationStackProvider) GetFlightsNear(airportICAO string, direction FlightDirection) ([]Flight, error) {
+func (a *AviationStackProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
 	params := url.Values{
 		"access_key":    {a.apiKey},
 		"flight_status": {"active"},
@@ -44,12 +45,19 @@ func (a *AviationStackProvider) GetFlightsNear(airportICAO string, direction Fli
 	}
 
 	u := aviationstackBaseURL + "/flights?" + params.Encode()
-	resp, err := a.httpClient.Get(u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("aviationstack: %w", err)
+	}
+	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("aviationstack: request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := checkQuota(ctx, a.Name(), resp); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("aviationstack: HTTP %d", resp.StatusCode)
 	}
@@ -71,7 +79,7 @@ func (a *AviationStackProvider) GetFlightsNear(airportICAO string, direction Fli
 
 // GetFlightPosition returns position for an AviationStack flight.
 // AviationStack includes live data in the flight endpoint.
-func (a *AviationStackProvider) GetFlightPosition(flight *Flight) (*FlightPosition, error) {
+func (a *AviationStackProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
 	// Use best available flight identifier
 	flightCode := flight.IdentIATA
 	paramKey := "flight_iata"
@@ -91,12 +99,19 @@ func (a *AviationStackProvider) GetFlightPosition(flight *Flight) (*FlightPositi
 	}
 
 	u := aviationstackBaseURL + "/flights?" + params.Encode()
-	resp, err := a.httpClient.Get(u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("aviationstack: %w", err)
+	}
+	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("aviationstack: request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := checkQuota(ctx, a.Name(), resp); err != nil {
+		return nil, err
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("aviationstack: HTTP %d", resp.StatusCode)
 	}
@@ -107,7 +122,7 @@ func (a *AviationStackProvider) GetFlightPosition(flight *Flight) (*FlightPositi
 	}
 
 	if len(raw.Data) == 0 {
-		return nil, fmt.Errorf("aviationstack: flight not found")
+		return nil, fmt.Errorf("aviationstack: %w", ErrNotFound)
 	}
 
 	live := raw.Data[0].Live
@@ -116,22 +131,22 @@ func (a *AviationStackProvider) GetFlightPosition(flight *Flight) (*FlightPositi
 	}
 
 	pos := &FlightPosition{
-		Latitude:    live.Latitude,
-		Longitude:   live.Longitude,
-		Altitude:    int(live.Altitude * 3.28084 / 100),  // meters → flight levels
-		Groundspeed: int(live.SpeedHorizontal * 1.94384), // m/s → knots
-		Timestamp:   time.Now(),
-	}
-
+		Latitude:     live.Latitude,
+		Longitude:    live.Longitude,
+		BaroAltitude: metresToFeet(live.Altitude),
+		Groundspeed:  metresPerSecToKnots(live.SpeedHorizontal),
+		Timestamp:    time.Now(),
+	}
+	if t, err := time.Parse(time.RFC3339, live.Updated); err == nil {
+		pos.Timestamp = t
+		pos.LastContact = t
+	}
+	// speed_vertical is m/s, like OpenSky's
+	vr := metresPerSecToFPM(live.SpeedVertical)
 	if live.IsGround {
-		pos.AltitudeChange = "-"
-	} else if live.SpeedVertical > 1 {
-		pos.AltitudeChange = "C"
-	} else if live.SpeedVertical < -1 {
-		pos.AltitudeChange = "D"
-	} else {
-		pos.AltitudeChange = "-"
+		vr = 0
 	}
+	pos.VerticalRate = &vr
 
 	if live.Direction > 0 {
 		h := int(live.Direction)
@@ -154,6 +169,7 @@ type asFlight struct {
 	Arrival      *asAirport    `json:"arrival"`
 	Airline      *asAirline    `json:"airline"`
 	Flight       *asFlightInfo `json:"flight"`
+	Aircraft     *asAircraft   `json:"aircraft"`
 	Live         *asLive       `json:"live"`
 }
 
@@ -175,6 +191,13 @@ type asFlightInfo struct {
 	ICAO   string `json:"icao"`
 }
 
+type asAircraft struct {
+	Registration string `json:"registration"`
+	IATA         string `json:"iata"`
+	ICAO         string `json:"icao"`
+	ICAO24       string `json:"icao24"`
+}
+
 type asLive struct {
 	Latitude        float64 `json:"latitude"`
 	Longitude       float64 `json:"longitude"`
@@ -183,6 +206,7 @@ type asLive struct {
 	SpeedHorizontal float64 `json:"speed_horizontal"`
 	SpeedVertical   float64 `json:"speed_vertical"`
 	IsGround        bool    `json:"is_ground"`
+	Updated         string  `json:"updated"` // RFC 3339, when the position was reported
 }
 
 func (f *asFlight) toFlight() Flight {
@@ -196,6 +220,13 @@ func (f *asFlight) toFlight() Flight {
 		flight.IdentICAO = f.Flight.ICAO
 		flight.FlightID = f.Flight.IATA // Use IATA code as ID for position lookups
 	}
+	if f.Aircraft != nil {
+		flight.Registration = f.Aircraft.Registration
+		flight.AircraftType = f.Aircraft.ICAO
+		if isHexAddr(f.Aircraft.ICAO24) {
+			flight.ICAO24 = strings.ToLower(f.Aircraft.ICAO24)
+		}
+	}
 	if f.Airline != nil {
 		flight.Operator = f.Airline.Name
 		flight.OperatorIATA = f.Airline.IATA
@@ -207,8 +238,8 @@ func (f *asFlight) toFlight() Flight {
 			CodeICAO: f.Departure.ICAO,
 			CodeIATA: f.Departure.IATA,
 			Name:     f.Departure.Airport,
-			City:     f.Departure.Airport,
 		}
+		completeAirport(flight.Origin)
 	}
 	if f.Arrival != nil {
 		flight.Destination = &AirportRef{
@@ -216,8 +247,8 @@ func (f *asFlight) toFlight() Flight {
 			CodeICAO: f.Arrival.ICAO,
 			CodeIATA: f.Arrival.IATA,
 			Name:     f.Arrival.Airport,
-			City:     f.Arrival.Airport,
 		}
+		completeAirport(flight.Destination)
 	}
 	flight.Status = f.FlightStatus
 	return flight
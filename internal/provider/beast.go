@@ -0,0 +1,401 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	beastEsc = 0x1a
+
+	beastDialTimeout = 10 * time.Second
+	beastReadTimeout = 60 * time.Second
+	beastMaxBackoff  = 30 * time.Second
+
+	// cprPairWindow is the maximum age difference of an even/odd pair used for
+	// global CPR decoding.
+	cprPairWindow = 10 * time.Second
+	// cprMaxLocalNM is the maximum distance of a locally decoded position from
+	// its reference before it is treated as a decoding error.
+	cprMaxLocalNM = 180.0
+)
+
+// beastFrame is one frame from a Beast binary stream.
+type beastFrame struct {
+	kind      byte   // '1' Mode A/C, '2' Mode S short, '3' Mode S long
+	timestamp uint64 // 48-bit 12MHz MLAT counter
+	signal    byte
+	msg       []byte
+}
+
+// beastReader splits a Beast binary stream (as served on port 30005) into frames.
+//
+// Frame layout: <esc> <type> <6-byte timestamp> <signal> <message>, where any
+// 0x1a byte after the type is doubled. A lone 0x1a mid-frame means the frame
+// was truncated and a new one starts.
+type beastReader struct {
+	r *bufio.Reader
+}
+
+func newBeastReader(r io.Reader) *beastReader {
+	return &beastReader{r: bufio.NewReader(r)}
+}
+
+// beastMessageLen returns the message length for a frame type, or 0 if unknown.
+func beastMessageLen(kind byte) int {
+	switch kind {
+	case '1':
+		return 2
+	case '2':
+		return 7
+	case '3':
+		return 14
+	}
+	return 0
+}
+
+// next returns the next complete frame, resynchronising on framing errors.
+func (br *beastReader) next() (beastFrame, error) {
+	synced := false // true when the escape byte has already been consumed
+	for {
+		if !synced {
+			b, err := br.r.ReadByte()
+			if err != nil {
+				return beastFrame{}, err
+			}
+			if b != beastEsc {
+				continue
+			}
+		}
+		synced = false
+
+		kind, err := br.r.ReadByte()
+		if err != nil {
+			return beastFrame{}, err
+		}
+		n := beastMessageLen(kind)
+		if n == 0 {
+			continue // status frame or garbage
+		}
+
+		buf, truncated, err := br.readEscaped(7 + n)
+		if err != nil {
+			return beastFrame{}, err
+		}
+		if truncated {
+			synced = true
+			continue
+		}
+
+		var ts uint64
+		for _, b := range buf[:6] {
+			ts = ts<<8 | uint64(b)
+		}
+		return beastFrame{kind: kind, timestamp: ts, signal: buf[6], msg: buf[7:]}, nil
+	}
+}
+
+// readEscaped reads n unescaped bytes. truncated is true if a new frame
+// started before n bytes were read; the escape byte has then been consumed.
+func (br *beastReader) readEscaped(n int) (buf []byte, truncated bool, err error) {
+	buf = make([]byte, n)
+	for i := 0; i < n; i++ {
+		b, err := br.r.ReadByte()
+		if err != nil {
+			return nil, false, err
+		}
+		if b == beastEsc {
+			b2, err := br.r.ReadByte()
+			if err != nil {
+				return nil, false, err
+			}
+			if b2 != beastEsc {
+				br.r.UnreadByte()
+				return nil, true, nil
+			}
+		}
+		buf[i] = b
+	}
+	return buf, false, nil
+}
+
+// cprFrame is one CPR-encoded airborne position.
+type cprFrame struct {
+	lat, lon int
+	at       time.Time
+}
+
+// cprState holds per-aircraft CPR decoding state.
+type cprState struct {
+	even, odd        cprFrame
+	lastLat, lastLon float64
+	lastFix          time.Time
+}
+
+// BeastProvider implements FlightProvider from a raw Mode S Beast binary feed
+// (TCP port 30005), decoding ADS-B identification, position and velocity
+// itself. Works with any feeder that exposes Beast output, including those
+// without an SBS port.
+type BeastProvider struct {
+	addr      string
+	table     *aircraftTable
+	connected atomic.Bool
+
+	refMu          sync.Mutex
+	refLat, refLon float64 // receiver location for local CPR decoding
+	hasRef         bool
+
+	// Decoder state, only touched by the Run goroutine.
+	cpr   map[string]*cprState
+	known map[string]time.Time // addresses confirmed by DF11/17/18
+}
+
+// NewBeastProvider creates a provider reading the Beast feed at addr
+// (e.g. "localhost:30005"). Call Run to start consuming the feed.
+func NewBeastProvider(addr string) *BeastProvider {
+	return &BeastProvider{
+		addr:  addr,
+		table: newAircraftTable(),
+		cpr:   make(map[string]*cprState),
+		known: make(map[string]time.Time),
+	}
+}
+
+func (b *BeastProvider) Name() string { return "beast" }
+
+// SetReceiverLocation sets the receiver position used to decode single CPR
+// frames before an even/odd pair has been received.
+func (b *BeastProvider) SetReceiverLocation(lat, lon float64) {
+	b.refMu.Lock()
+	defer b.refMu.Unlock()
+	b.refLat, b.refLon, b.hasRef = lat, lon, true
+}
+
+// Run reads the feed, reconnecting with backoff whenever it drops.
+// Blocks until ctx is cancelled — run in a goroutine.
+func (b *BeastProvider) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := b.readFeed(ctx)
+		b.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(start) > beastMaxBackoff {
+			backoff = time.Second
+		}
+		log.Printf("[beast] feed %s: %v, reconnecting in %v", b.addr, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > beastMaxBackoff {
+			backoff = beastMaxBackoff
+		}
+	}
+}
+
+// readFeed connects once and decodes frames until the connection fails.
+func (b *BeastProvider) readFeed(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: beastDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock the pending read when the context is cancelled.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	log.Printf("[beast] connected to %s", b.addr)
+	b.connected.Store(true)
+
+	br := newBeastReader(conn)
+	lastPrune := time.Now()
+	for {
+		conn.SetReadDeadline(time.Now().Add(beastReadTimeout))
+		frame, err := br.next()
+		if err != nil {
+			return err
+		}
+		if frame.kind == '1' {
+			continue // Mode A/C carries no address
+		}
+		b.handleMessage(frame.msg)
+
+		if time.Since(lastPrune) > localMaxAge {
+			b.pruneDecoderState()
+			lastPrune = time.Now()
+		}
+	}
+}
+
+// handleMessage decodes one Mode S message and applies it to the aircraft table.
+func (b *BeastProvider) handleMessage(msg []byte) {
+	m, err := decodeModeS(msg)
+	if err != nil {
+		return // CRC failures and unsupported formats are routine
+	}
+	icao := m.icaoHex()
+	now := time.Now()
+
+	switch m.df {
+	case 11, 17, 18:
+		b.known[icao] = now
+	default:
+		// Address/parity replies are only trusted for aircraft already heard.
+		if _, ok := b.known[icao]; !ok {
+			return
+		}
+	}
+
+	var lat, lon float64
+	var hasFix bool
+	if m.hasCPR {
+		lat, lon, hasFix = b.decodePosition(icao, m, now)
+	}
+
+	b.table.update(icao, func(a *localAircraft) {
+		if m.callsign != "" {
+			a.callsign = m.callsign
+		}
+		if m.category != "" {
+			a.category = m.category
+		}
+		if m.hasAltitude {
+			a.altitude = Feet(m.altitude)
+		}
+		if m.hasGeoAltitude {
+			a.geoAltitude = Feet(m.geoAltitude)
+		}
+		if m.hasVelocity {
+			a.groundspeed = Knots(m.groundspeed)
+			trk := m.track
+			a.track = &trk
+		}
+		if m.hasVertRate {
+			a.vertRate = FeetPerMinute(m.vertRate)
+			a.hasVertRate = true
+		}
+		if m.squawk != "" {
+			a.squawk = m.squawk
+		}
+		if m.onGround {
+			a.onGround = true
+		} else if m.hasCPR {
+			a.onGround = false
+		}
+		if hasFix {
+			a.lat, a.lon = lat, lon
+			a.hasPos = true
+			a.posSource = SourceADSB // DF17/18 extended squitter
+			a.lastPos = now
+		}
+	})
+}
+
+// decodePosition resolves a CPR frame to a position. It uses global decoding
+// when a recent even/odd pair is available and otherwise decodes locally
+// relative to the aircraft's last fix or the receiver location.
+func (b *BeastProvider) decodePosition(icao string, m *modesMessage, now time.Time) (float64, float64, bool) {
+	st, ok := b.cpr[icao]
+	if !ok {
+		st = &cprState{}
+		b.cpr[icao] = st
+	}
+
+	frame := cprFrame{lat: m.cprLat, lon: m.cprLon, at: now}
+	if m.cprOdd {
+		st.odd = frame
+	} else {
+		st.even = frame
+	}
+
+	var lat, lon float64
+	decoded := false
+
+	if !st.even.at.IsZero() && !st.odd.at.IsZero() &&
+		absDuration(st.even.at.Sub(st.odd.at)) <= cprPairWindow {
+		lat, lon, decoded = cprGlobal(st.even.lat, st.even.lon, st.odd.lat, st.odd.lon, m.cprOdd)
+	}
+
+	if !decoded {
+		refLat, refLon, hasRef := st.lastLat, st.lastLon, now.Sub(st.lastFix) < localMaxAge
+		if !hasRef {
+			b.refMu.Lock()
+			refLat, refLon, hasRef = b.refLat, b.refLon, b.hasRef
+			b.refMu.Unlock()
+		}
+		if !hasRef {
+			return 0, 0, false
+		}
+		lat, lon = cprLocal(m.cprLat, m.cprLon, m.cprOdd, refLat, refLon)
+		if distanceNM(refLat, refLon, lat, lon) > cprMaxLocalNM {
+			return 0, 0, false
+		}
+	}
+
+	if lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	st.lastLat, st.lastLon, st.lastFix = lat, lon, now
+	return lat, lon, true
+}
+
+// pruneDecoderState drops CPR and address state for aircraft no longer heard.
+func (b *BeastProvider) pruneDecoderState() {
+	cutoff := time.Now().Add(-localMaxAge)
+	for icao, seen := range b.known {
+		if seen.Before(cutoff) {
+			delete(b.known, icao)
+			delete(b.cpr, icao)
+		}
+	}
+}
+
+// GetFlightsNear returns airborne aircraft heard around the airport.
+// The feed carries no route data, so direction is ignored.
+func (b *BeastProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	if !b.connected.Load() {
+		return nil, fmt.Errorf("beast: not connected to %s", b.addr)
+	}
+	lat, lon := airportCoords(airportICAO)
+	return b.table.flightsNear(lat, lon, 1.0), nil
+}
+
+// GetPositionsNear returns every aircraft heard around the airport.
+func (b *BeastProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	if !b.connected.Load() {
+		return nil, fmt.Errorf("beast: not connected to %s", b.addr)
+	}
+	lat, lon := airportCoords(airportICAO)
+	return b.table.snapshot(lat, lon, 1.0), nil
+}
+
+// GetFlightPosition returns the latest decoded position for the flight.
+func (b *BeastProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	pos, ok := b.table.position(flight)
+	if !ok {
+		return nil, fmt.Errorf("beast: %q not heard recently: %w", flight.Ident, ErrNotFound)
+	}
+	return pos, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// beastBytes encodes a frame as a Beast feed sends it, doubling any escape
+// byte after the type.
+func beastBytes(kind byte, ts uint64, signal byte, msg []byte) []byte {
+	body := []byte{byte(ts >> 40), byte(ts >> 32), byte(ts >> 24), byte(ts >> 16), byte(ts >> 8), byte(ts), signal}
+	body = append(body, msg...)
+	out := []byte{beastEsc, kind}
+	for _, b := range body {
+		out = append(out, b)
+		if b == beastEsc {
+			out = append(out, beastEsc)
+		}
+	}
+	return out
+}
+
+func TestBeastReader(t *testing.T) {
+	long := []byte{0x8D, 0x40, 0x6B, 0x90, 0x20, 0x15, 0xA6, 0x78, 0xD4, 0xD2, 0x20, 0xAA, 0x4B, 0xDA}
+	short := []byte{0x5D, 0x48, 0x40, 0xD6, 0x1A, 0x00, 0x01}
+	escaped := []byte{0x8D, 0x1A, 0x1A, 0x1A, 0x20, 0x15, 0xA6, 0x78, 0xD4, 0xD2, 0x20, 0xAA, 0x4B, 0x1A}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+	tests := []struct {
+		name   string
+		stream []byte
+		want   []beastFrame
+	}{
+		{
+			name:   "long frame",
+			stream: beastBytes('3', 0x0102030405, 0x80, long),
+			want:   []beastFrame{{'3', 0x0102030405, 0x80, long}},
+		},
+		{
+			name:   "short and Mode A/C frames",
+			stream: join(beastBytes('2', 1, 2, short), beastBytes('1', 3, 4, []byte{0x12, 0x34})),
+			want:   []beastFrame{{'2', 1, 2, short}, {'1', 3, 4, []byte{0x12, 0x34}}},
+		},
+		{
+			name:   "escapes un-doubled in timestamp, signal and message",
+			stream: beastBytes('3', 0x1a001a00001a, beastEsc, escaped),
+			want:   []beastFrame{{'3', 0x1a001a00001a, beastEsc, escaped}},
+		},
+		{
+			name:   "garbage before a frame",
+			stream: join([]byte{0x00, 0xff, 0x33, 0x31}, beastBytes('3', 7, 8, long)),
+			want:   []beastFrame{{'3', 7, 8, long}},
+		},
+		{
+			name:   "unknown frame type skipped",
+			stream: join([]byte{beastEsc, '4', 0x01, 0x02}, beastBytes('2', 1, 2, short)),
+			want:   []beastFrame{{'2', 1, 2, short}},
+		},
+		{
+			name:   "truncated frame dropped at the next escape",
+			stream: join(beastBytes('3', 5, 6, long)[:10], beastBytes('2', 1, 2, short)),
+			want:   []beastFrame{{'2', 1, 2, short}},
+		},
+		{
+			name:   "stream ends mid-frame",
+			stream: join(beastBytes('2', 1, 2, short), beastBytes('3', 5, 6, long)[:12]),
+			want:   []beastFrame{{'2', 1, 2, short}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := newBeastReader(bytes.NewReader(tt.stream))
+			var got []beastFrame
+			for {
+				f, err := br.next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("next: %v", err)
+				}
+				got = append(got, f)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d frames %v, want %d", len(got), got, len(tt.want))
+			}
+			for i, f := range got {
+				w := tt.want[i]
+				if f.kind != w.kind || f.timestamp != w.timestamp || f.signal != w.signal || !bytes.Equal(f.msg, w.msg) {
+					t.Errorf("frame %d = %c %x %x % x, want %c %x %x % x",
+						i, f.kind, f.timestamp, f.signal, f.msg, w.kind, w.timestamp, w.signal, w.msg)
+				}
+			}
+		})
+	}
+}
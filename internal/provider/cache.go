@@ -0,0 +1,338 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default CachingProvider freshness.
+const (
+	defaultCacheTTL    = 3 * time.Second  // a tick's two discovery calls share one fetch
+	defaultPositionTTL = 10 * time.Second // oldest snapshot a position may come from
+)
+
+// CachingProvider wraps a FlightProvider, reusing results for a short TTL and
+// coalescing identical concurrent requests into one upstream call.
+//
+// When the wrapped provider is an AreaProvider, GetFlightsNear and
+// GetPositionsNear are served from a snapshot of its search box.
+// GetFlightPosition is answered from the newest snapshot containing the
+// aircraft while it is within PositionTTL, so the featured poll usually costs
+// nothing.
+type CachingProvider struct {
+	inner FlightProvider
+	area  AreaProvider // inner, if it supports area snapshots
+
+	// TTL is how long any result, or an area snapshot, is reused.
+	TTL time.Duration
+	// PositionTTL is how old an area snapshot may be and still answer
+	// GetFlightPosition. It is usually longer than TTL: a slightly older
+	// position is better than spending a request on it.
+	PositionTTL time.Duration
+
+	mu        sync.Mutex
+	flights   map[string]cachedFlights
+	areas     map[BoundingBox]*AreaSnapshot
+	bulk      map[string]*AreaSnapshot  // GetPositionsNear results by airport
+	positions map[string]cachedPosition // by positionKey
+	calls     map[string]*inflightCall
+}
+
+type cachedFlights struct {
+	flights   []Flight
+	fetchedAt time.Time
+}
+
+type cachedPosition struct {
+	pos       FlightPosition
+	fetchedAt time.Time
+}
+
+// inflightCall is an upstream request that concurrent callers wait on.
+type inflightCall struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+// NewCachingProvider wraps inner with the default TTLs.
+func NewCachingProvider(inner FlightProvider) *CachingProvider {
+	c := &CachingProvider{
+		inner:       inner,
+		TTL:         defaultCacheTTL,
+		PositionTTL: defaultPositionTTL,
+		flights:     make(map[string]cachedFlights),
+		areas:       make(map[BoundingBox]*AreaSnapshot),
+		bulk:        make(map[string]*AreaSnapshot),
+		positions:   make(map[string]cachedPosition),
+		calls:       make(map[string]*inflightCall),
+	}
+	c.area, _ = inner.(AreaProvider)
+	return c
+}
+
+// Name returns the wrapped provider's name so rate limits and logs are unaffected.
+func (c *CachingProvider) Name() string { return c.inner.Name() }
+
+// Unwrap returns the wrapped provider.
+func (c *CachingProvider) Unwrap() FlightProvider { return c.inner }
+
+// Health forwards the wrapped provider's health report, if it has one.
+func (c *CachingProvider) Health() []ProviderHealth {
+	if hr, ok := c.inner.(HealthReporter); ok {
+		return hr.Health()
+	}
+	return nil
+}
+
+// GetFlightsNear returns the cached flight list if it is within TTL.
+func (c *CachingProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	if c.area != nil {
+		snap, err := c.snapshot(ctx, c.area.NearBox(airportICAO))
+		if err != nil {
+			return nil, err
+		}
+		return snap.Airborne(), nil
+	}
+
+	key := fmt.Sprintf("%s/%d", airportICAO, direction)
+	c.mu.Lock()
+	cached, ok := c.flights[key]
+	c.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < c.TTL {
+		reportCacheHit(ctx)
+		return append([]Flight(nil), cached.flights...), nil
+	}
+
+	val, err := c.do(ctx, "near:"+key, func() (any, error) {
+		flights, err := c.inner.GetFlightsNear(ctx, airportICAO, direction)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.flights[key] = cachedFlights{flights: flights, fetchedAt: time.Now()}
+		c.mu.Unlock()
+		return flights, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Callers tag and modify flights in place; give each its own copy.
+	return append([]Flight(nil), val.([]Flight)...), nil
+}
+
+// snapshot returns the area snapshot for box, fetching it if none is within TTL.
+func (c *CachingProvider) snapshot(ctx context.Context, box BoundingBox) (*AreaSnapshot, error) {
+	c.mu.Lock()
+	snap, ok := c.areas[box]
+	c.mu.Unlock()
+	if ok && time.Since(snap.FetchedAt) < c.TTL {
+		reportCacheHit(ctx)
+		return snap, nil
+	}
+
+	val, err := c.do(ctx, "area:"+box.String(), func() (any, error) {
+		snap, err := c.area.GetArea(ctx, box)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.areas[box] = snap
+		c.mu.Unlock()
+		return snap, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return val.(*AreaSnapshot), nil
+}
+
+// GetPositionsNear returns the wrapped provider's bulk positions, reusing a
+// snapshot within TTL. For an AreaProvider this is the GetFlightsNear snapshot.
+func (c *CachingProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	if c.area != nil {
+		return c.snapshot(ctx, c.area.NearBox(airportICAO))
+	}
+	bp, ok := bulkPositions(c.inner)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", c.Name(), errNoBulkPositions)
+	}
+
+	c.mu.Lock()
+	snap, ok := c.bulk[airportICAO]
+	c.mu.Unlock()
+	if ok && time.Since(snap.FetchedAt) < c.TTL {
+		reportCacheHit(ctx)
+		return snap, nil
+	}
+
+	val, err := c.do(ctx, "bulk:"+airportICAO, func() (any, error) {
+		snap, err := bp.GetPositionsNear(ctx, airportICAO)
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.bulk[airportICAO] = snap
+		c.mu.Unlock()
+		return snap, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return val.(*AreaSnapshot), nil
+}
+
+// cachedPositionsNear returns the snapshot GetPositionsNear would use if it is
+// within PositionTTL. Like a featured position, a slightly older radar fill
+// is better than spending a request on it.
+func (c *CachingProvider) cachedPositionsNear(airportICAO string) (*AreaSnapshot, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var snap *AreaSnapshot
+	if c.area != nil {
+		snap = c.areas[c.area.NearBox(airportICAO)]
+	} else {
+		snap = c.bulk[airportICAO]
+	}
+	if snap == nil || time.Since(snap.FetchedAt) >= c.PositionTTL {
+		return nil, false
+	}
+	return snap, true
+}
+
+// GetFlightPosition answers from an area snapshot within PositionTTL or a
+// position fetched within TTL if it can, and otherwise asks the wrapped
+// provider.
+func (c *CachingProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	if pos := c.cachedPosition(flight); pos != nil {
+		reportCacheHit(ctx)
+		return pos, nil
+	}
+
+	key := positionKey(flight)
+	val, err := c.do(ctx, "pos:"+key, func() (any, error) {
+		pos, err := c.inner.GetFlightPosition(ctx, flight)
+		if err != nil || pos == nil {
+			return pos, err
+		}
+		c.mu.Lock()
+		c.prunePositions()
+		c.positions[key] = cachedPosition{pos: *pos, fetchedAt: time.Now()}
+		c.mu.Unlock()
+		return pos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	pos, _ := val.(*FlightPosition)
+	if pos == nil {
+		return nil, nil
+	}
+	cp := *pos
+	return &cp, nil
+}
+
+// cachedPosition returns a copy of the newest usable cached position for
+// flight, or nil. Newest is by when the position was observed, so a snapshot
+// fetched later from a lagging source doesn't win over a fresher fix.
+func (c *CachingProvider) cachedPosition(flight *Flight) *FlightPosition {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best *FlightPosition
+	var bestAt time.Time
+	observed := func(pos *FlightPosition, fetched time.Time) time.Time {
+		if pos.Timestamp.IsZero() {
+			return fetched
+		}
+		return pos.Timestamp
+	}
+	consider := func(snap *AreaSnapshot) {
+		if time.Since(snap.FetchedAt) >= c.PositionTTL {
+			return
+		}
+		if pos, ok := snap.Find(flight); ok {
+			if at := observed(pos, snap.FetchedAt); best == nil || at.After(bestAt) {
+				best, bestAt = pos, at
+			}
+		}
+	}
+	for _, snap := range c.areas {
+		consider(snap)
+	}
+	for _, snap := range c.bulk {
+		consider(snap)
+	}
+	if cached, ok := c.positions[positionKey(flight)]; ok && time.Since(cached.fetchedAt) < c.TTL {
+		if pos := cached.pos; best == nil || observed(&pos, cached.fetchedAt).After(bestAt) {
+			best = &pos
+		}
+	}
+	return best
+}
+
+// prunePositions drops expired positions. Must be called with mu held.
+func (c *CachingProvider) prunePositions() {
+	for k, cached := range c.positions {
+		if time.Since(cached.fetchedAt) >= c.TTL {
+			delete(c.positions, k)
+		}
+	}
+}
+
+// positionKey identifies the aircraft a position lookup is for.
+func positionKey(f *Flight) string {
+	if hex := f.TransponderHex(); hex != "" {
+		return "hex:" + hex
+	}
+	if f.Ident != "" {
+		return "cs:" + strings.ToUpper(f.Ident)
+	}
+	return "id:" + f.FlightID
+}
+
+// do runs fn unless a call with the same key is already in flight, in which
+// case it waits for that call's result instead. A waiter whose own ctx is
+// cancelled returns early; the shared call runs on the first caller's ctx.
+func (c *CachingProvider) do(ctx context.Context, key string, fn func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			reportCacheHit(ctx)
+			return call.val, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &inflightCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.val, call.err = fn()
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.val, call.err
+}
+
+type cacheHitKey struct{}
+
+// withCacheHitReporter returns a context whose provider calls invoke fn when
+// they are answered without an upstream request.
+func withCacheHitReporter(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, cacheHitKey{}, fn)
+}
+
+// reportCacheHit tells the caller registered in ctx that no request was made.
+func reportCacheHit(ctx context.Context) {
+	if fn, ok := ctx.Value(cacheHitKey{}).(func()); ok {
+		fn()
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import "strings"
+
+// sourceFlights is one provider's GetFlightsNear result.
+type sourceFlights struct {
+	provider string
+	flights  []Flight
+}
+
+// fuseFlights reconciles flights reported by several providers into one Flight
+// per aircraft. Results must be in priority order: for every field the first
+// source with a value wins, and the winner is recorded in Provenance.
+//
+// Flights are matched by ICAO24 address, registration or callsign, so a local
+// receiver's transponder hex can pick up AeroAPI's route for the same aircraft.
+func fuseFlights(results []sourceFlights) []Flight {
+	var fused []*Flight
+	byKey := make(map[string]*Flight)
+
+	for _, res := range results {
+		for i := range res.flights {
+			src := &res.flights[i]
+			keys := fusionKeys(src)
+
+			var dst *Flight
+			for _, k := range keys {
+				if f, ok := byKey[k]; ok {
+					dst = f
+					break
+				}
+			}
+			if dst == nil {
+				dst = &Flight{
+					SourceProvider: res.provider,
+					IsAirborne:     src.IsAirborne,
+					Provenance:     make(map[string]string),
+					SourceIDs:      make(map[string]string),
+				}
+				fused = append(fused, dst)
+			}
+			if _, seen := dst.SourceIDs[res.provider]; seen {
+				continue // provider listed the aircraft twice; keep its first entry
+			}
+			mergeFlight(dst, src, res.provider)
+
+			// Index under the merged keys so later sources can match any of them.
+			for _, k := range fusionKeys(dst) {
+				if _, ok := byKey[k]; !ok {
+					byKey[k] = dst
+				}
+			}
+		}
+	}
+
+	flights := make([]Flight, len(fused))
+	for i, f := range fused {
+		flights[i] = *f
+	}
+	return flights
+}
+
+// fusionKeys returns the identities a flight can be matched on.
+func fusionKeys(f *Flight) []string {
+	var keys []string
+	if hex := f.TransponderHex(); hex != "" {
+		keys = append(keys, "hex:"+hex)
+	}
+	if reg := normalizeRegistration(f.Registration); reg != "" {
+		keys = append(keys, "reg:"+reg)
+	}
+	for _, cs := range []string{f.IdentICAO, f.Ident, f.IdentIATA} {
+		if cs = strings.ToUpper(strings.TrimSpace(cs)); cs != "" {
+			keys = append(keys, "cs:"+cs)
+		}
+	}
+	return keys
+}
+
+// normalizeRegistration strips punctuation so "N-372UA" and "N372UA" match.
+func normalizeRegistration(reg string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(reg))
+}
+
+// mergeFlight fills the empty fields of dst from src, recording provenance.
+func mergeFlight(dst, src *Flight, provider string) {
+	dst.SourceIDs[provider] = src.FlightID
+	if dst.FlightID == "" {
+		dst.FlightID = src.FlightID
+	}
+	if src.TransponderHex() != "" && dst.ICAO24 == "" {
+		dst.ICAO24 = src.TransponderHex()
+		dst.Provenance["ICAO24"] = provider
+	}
+
+	mergeString(dst, "Ident", &dst.Ident, src.Ident, provider)
+	mergeString(dst, "IdentICAO", &dst.IdentICAO, src.IdentICAO, provider)
+	mergeString(dst, "IdentIATA", &dst.IdentIATA, src.IdentIATA, provider)
+	mergeString(dst, "Registration", &dst.Registration, src.Registration, provider)
+	mergeString(dst, "Operator", &dst.Operator, src.Operator, provider)
+	mergeString(dst, "OperatorICAO", &dst.OperatorICAO, src.OperatorICAO, provider)
+	mergeString(dst, "OperatorIATA", &dst.OperatorIATA, src.OperatorIATA, provider)
+	mergeString(dst, "FlightNumber", &dst.FlightNumber, src.FlightNumber, provider)
+	mergeString(dst, "Status", &dst.Status, src.Status, provider)
+	mergeString(dst, "AircraftType", &dst.AircraftType, src.AircraftType, provider)
+	if dst.Category == "" && src.Category != "" {
+		dst.Category = src.Category
+		dst.Provenance["Category"] = provider
+	}
+
+	if dst.Origin == nil && src.Origin != nil {
+		dst.Origin = src.Origin
+		dst.Provenance["Origin"] = provider
+	}
+	if dst.Destination == nil && src.Destination != nil {
+		dst.Destination = src.Destination
+		dst.Provenance["Destination"] = provider
+	}
+}
+
+func mergeString(dst *Flight, field string, to *string, from, provider string) {
+	if *to == "" && from != "" {
+		*to = from
+		dst.Provenance[field] = provider
+	}
+}
@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// healthAlpha is the EWMA weight of the newest sample.
+	healthAlpha = 0.2
+	// healthSlowLatency is the latency at which a provider's score is halved.
+	healthSlowLatency = 5 * time.Second
+
+	// breakerThreshold is the number of consecutive failures that opens the breaker.
+	breakerThreshold = 3
+	// breakerCooldown is how long an open breaker waits before a probe request.
+	// It doubles on every failed probe, up to breakerMaxCooldown.
+	breakerCooldown    = 30 * time.Second
+	breakerMaxCooldown = 10 * time.Minute
+)
+
+// BreakerState is the state of a provider's circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // healthy, requests flow
+	BreakerOpen                         // failing, requests are skipped
+	BreakerHalfOpen                     // cooldown over, one probe request allowed
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// ProviderHealth is a snapshot of one provider's health and quota, for the UI and logs.
+type ProviderHealth struct {
+	Name                string
+	State               BreakerState
+	ErrorRate           float64       // EWMA of failures, 0.0 to 1.0
+	Latency             time.Duration // EWMA of request latency
+	ConsecutiveFailures int
+	LastError           string
+	RetryIn             time.Duration // time until an open breaker allows a probe
+	Capacity            float64       // remaining rate-limit capacity, 0.0 to 1.0 (1.0 if unlimited)
+	Remaining           int           // requests left in the rate-limit window, -1 if unlimited
+	DiscoveryRemaining  int           // of those, how many discovery may use; -1 if unlimited
+	Window              time.Duration // time over which Remaining must last
+	Score               float64       // ranking score: health blended with capacity
+	Rejected            int           // positions rejected as implausible
+}
+
+// HealthReporter is implemented by providers that can report per-provider health.
+type HealthReporter interface {
+	Health() []ProviderHealth
+}
+
+// providerHealth tracks a provider's error rate and latency and runs its
+// circuit breaker.
+type providerHealth struct {
+	name string
+
+	mu          sync.Mutex
+	errRate     float64
+	latency     time.Duration
+	consecutive int
+	lastErr     string
+	rejected    int
+
+	state    BreakerState
+	openedAt time.Time
+	cooldown time.Duration
+	probing  bool // a half-open probe is in flight
+}
+
+func newProviderHealth(name string) *providerHealth {
+	return &providerHealth{name: name, cooldown: breakerCooldown}
+}
+
+// probeDue reports whether the breaker is open and its cooldown has passed,
+// so the next request would be a half-open probe.
+func (h *providerHealth) probeDue() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state == BreakerOpen && time.Since(h.openedAt) >= h.cooldown
+}
+
+// allow reports whether a request may be sent. An open breaker whose cooldown
+// has passed moves to half-open and admits exactly one probe.
+func (h *providerHealth) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch h.state {
+	case BreakerOpen:
+		if time.Since(h.openedAt) < h.cooldown {
+			return false
+		}
+		h.state = BreakerHalfOpen
+		h.probing = true
+		log.Printf("[health] %s: circuit half-open, probing", h.name)
+		return true
+	case BreakerHalfOpen:
+		if h.probing {
+			return false
+		}
+		h.probing = true
+		return true
+	}
+	return true
+}
+
+// observe records the outcome of a request. Cancellation by the caller and
+// server quota rejections (handled by the rate limit) are ignored;
+// ErrNotFound counts as a success since the provider answered.
+func (h *providerHealth) observe(latency time.Duration, err error) {
+	if errors.Is(err, ErrNotFound) {
+		err = nil
+	}
+	var limited *RateLimitedError
+	if errors.Is(err, context.Canceled) || errors.As(err, &limited) {
+		h.mu.Lock()
+		h.probing = false
+		h.mu.Unlock()
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sample := 0.0
+	if err != nil {
+		sample = 1.0
+	}
+	h.errRate += healthAlpha * (sample - h.errRate)
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency += time.Duration(healthAlpha * float64(latency-h.latency))
+	}
+	h.probing = false
+
+	if err == nil {
+		if h.state != BreakerClosed {
+			log.Printf("[health] %s: circuit closed, provider recovered", h.name)
+		}
+		h.consecutive = 0
+		h.state = BreakerClosed
+		h.cooldown = breakerCooldown
+		return
+	}
+
+	h.consecutive++
+	h.lastErr = err.Error()
+	switch {
+	case h.state == BreakerHalfOpen:
+		// Probe failed — back off harder.
+		h.cooldown *= 2
+		if h.cooldown > breakerMaxCooldown {
+			h.cooldown = breakerMaxCooldown
+		}
+		h.state = BreakerOpen
+		h.openedAt = time.Now()
+		log.Printf("[health] %s: probe failed, circuit open for %v: %v", h.name, h.cooldown, err)
+	case h.state == BreakerClosed && h.consecutive >= breakerThreshold:
+		h.state = BreakerOpen
+		h.openedAt = time.Now()
+		log.Printf("[health] %s: %d consecutive failures, circuit open for %v: %v",
+			h.name, h.consecutive, h.cooldown, err)
+	}
+}
+
+// reject counts a position the provider reported that failed validation. It
+// doesn't affect the breaker: the provider answered, just not credibly.
+func (h *providerHealth) reject() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rejected++
+	return h.rejected
+}
+
+// score returns 0.0 (circuit open) to 1.0 (healthy and fast).
+func (h *providerHealth) score() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != BreakerClosed {
+		return 0
+	}
+	speed := 1 / (1 + float64(h.latency)/float64(healthSlowLatency))
+	return (1 - h.errRate) * speed
+}
+
+// snapshot returns the exported view of the health state.
+func (h *providerHealth) snapshot() ProviderHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ph := ProviderHealth{
+		Name:                h.name,
+		State:               h.state,
+		ErrorRate:           h.errRate,
+		Latency:             h.latency,
+		ConsecutiveFailures: h.consecutive,
+		LastError:           h.lastErr,
+		Rejected:            h.rejected,
+	}
+	if h.state == BreakerOpen {
+		if wait := h.cooldown - time.Since(h.openedAt); wait > 0 {
+			ph.RetryIn = wait
+		}
+	}
+	return ph
+}
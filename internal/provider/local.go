@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localMaxAge is how long an aircraft stays in the table after it was last heard.
+const localMaxAge = 60 * time.Second
+
+// localAircraft is the state of one aircraft assembled from a local receiver feed.
+type localAircraft struct {
+	icao24      string
+	callsign    string
+	category    EmitterCategory
+	altitude    Feet // barometric
+	geoAltitude Feet
+	groundspeed Knots
+	track       *int
+	vertRate    FeetPerMinute
+	hasVertRate bool
+	squawk      string
+	lat, lon    float64
+	hasPos      bool
+	posSource   PositionSource
+	onGround    bool
+	lastSeen    time.Time
+	lastPos     time.Time
+}
+
+// aircraftTable is the in-memory traffic picture kept by receiver-backed providers.
+// Entries are keyed by lowercase ICAO24 hex address.
+type aircraftTable struct {
+	mu       sync.Mutex
+	aircraft map[string]*localAircraft
+}
+
+func newAircraftTable() *aircraftTable {
+	return &aircraftTable{aircraft: make(map[string]*localAircraft)}
+}
+
+// update applies fn to the aircraft with the given ICAO24, creating it if needed.
+func (t *aircraftTable) update(icao24 string, fn func(a *localAircraft)) {
+	icao24 = strings.ToLower(icao24)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.aircraft[icao24]
+	if !ok {
+		a = &localAircraft{icao24: icao24}
+		t.aircraft[icao24] = a
+	}
+	a.lastSeen = time.Now()
+	fn(a)
+}
+
+// prune removes aircraft not heard within localMaxAge.
+// Must be called with mu held.
+func (t *aircraftTable) prune() {
+	cutoff := time.Now().Add(-localMaxAge)
+	for k, a := range t.aircraft {
+		if a.lastSeen.Before(cutoff) {
+			delete(t.aircraft, k)
+		}
+	}
+}
+
+// flightsNear returns airborne aircraft with a callsign and a position inside
+// a box of ±delta degrees around the given point.
+func (t *aircraftTable) flightsNear(lat, lon, delta float64) []Flight {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune()
+
+	var flights []Flight
+	for _, a := range t.aircraft {
+		if a.callsign == "" || !a.hasPos || a.onGround {
+			continue
+		}
+		if a.lat < lat-delta || a.lat > lat+delta || a.lon < lon-delta || a.lon > lon+delta {
+			continue
+		}
+		flights = append(flights, a.toFlight())
+	}
+	return flights
+}
+
+// snapshot returns every aircraft with a position inside a box of ±delta
+// degrees around the given point.
+func (t *aircraftTable) snapshot(lat, lon, delta float64) *AreaSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune()
+
+	snap := &AreaSnapshot{Box: boxAround(lat, lon, delta), FetchedAt: time.Now()}
+	for _, a := range t.aircraft {
+		if !a.hasPos || !snap.Box.Contains(a.lat, a.lon) {
+			continue
+		}
+		snap.Flights = append(snap.Flights, a.toFlight())
+		snap.Positions = append(snap.Positions, a.toPosition())
+	}
+	return snap
+}
+
+// position returns the latest position for a flight, matched by ICAO24 hex
+// first and then by callsign.
+func (t *aircraftTable) position(flight *Flight) (*FlightPosition, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.prune()
+
+	var match *localAircraft
+	if hex := flight.TransponderHex(); hex != "" {
+		match = t.aircraft[hex]
+	}
+	if match == nil {
+		for _, a := range t.aircraft {
+			if a.callsign == "" {
+				continue
+			}
+			if strings.EqualFold(a.callsign, flight.Ident) || strings.EqualFold(a.callsign, flight.IdentICAO) {
+				match = a
+				break
+			}
+		}
+	}
+	if match == nil || !match.hasPos {
+		return nil, false
+	}
+
+	pos := match.toPosition()
+	return &pos, true
+}
+
+func (a *localAircraft) toFlight() Flight {
+	f := Flight{
+		FlightID:   a.icao24, // ICAO24 transponder hex
+		ICAO24:     a.icao24,
+		Category:   a.category,
+		IsAirborne: !a.onGround,
+	}
+	applyCallsign(&f, a.callsign)
+	return f
+}
+
+func (a *localAircraft) toPosition() FlightPosition {
+	pos := FlightPosition{
+		BaroAltitude: a.altitude,
+		GeoAltitude:  a.geoAltitude,
+		Groundspeed:  a.groundspeed,
+		Heading:      a.track,
+		Latitude:     a.lat,
+		Longitude:    a.lon,
+		Squawk:       a.squawk,
+		Source:       a.posSource,
+		Timestamp:    a.lastPos,
+		LastContact:  a.lastSeen,
+	}
+	if a.hasVertRate {
+		vr := a.vertRate
+		pos.VerticalRate = &vr
+	}
+	return pos
+}
+
+// altitudeChangeFPM maps a vertical rate to the "C"/"D"/"-" convention of
+// FlightPosition.Trend. The ±200 ft/min dead band matches OpenSky's ±1 m/s.
+func altitudeChangeFPM(fpm FeetPerMinute) string {
+	switch {
+	case fpm > 200:
+		return "C"
+	case fpm < -200:
+		return "D"
+	default:
+		return "-"
+	}
+}
+
+// distanceNM returns the great-circle distance between two points in nautical miles.
+func distanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusNM = 3440.065
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusNM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,352 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Mode S / ADS-B decoding for raw receiver output.
+// Reference: "The 1090 Megahertz Riddle" (Junzi Sun), ICAO Annex 10 Vol IV.
+
+// modesGenerator is the Mode S CRC-24 generator polynomial (25 bits).
+const modesGenerator = 0x1FFF409
+
+// modesCharset maps 6-bit identification characters to ASCII.
+const modesCharset = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ##### ###############0123456789######"
+
+// modesMessage holds the fields decoded from one Mode S message.
+// Only the fields relevant to the message type are set.
+type modesMessage struct {
+	df     int
+	icao24 uint32
+
+	callsign string
+	category EmitterCategory
+
+	altitude       int // feet, barometric
+	hasAltitude    bool
+	geoAltitude    int // feet, GNSS
+	hasGeoAltitude bool
+
+	// Airborne position (CPR encoded)
+	hasCPR bool
+	cprOdd bool
+	cprLat int // 17-bit
+	cprLon int // 17-bit
+
+	onGround bool
+
+	groundspeed int // knots
+	track       int // degrees
+	hasVelocity bool
+	vertRate    int // feet per minute
+	hasVertRate bool
+
+	squawk string
+}
+
+// icaoHex returns the message's ICAO24 address as lowercase hex.
+func (m *modesMessage) icaoHex() string {
+	return fmt.Sprintf("%06x", m.icao24)
+}
+
+// modesCRC returns the CRC-24 remainder over all but the last 24 bits of msg.
+func modesCRC(msg []byte) uint32 {
+	var crc uint32
+	for _, b := range msg[:len(msg)-3] {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= modesGenerator
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
+
+// modesParity returns the 24-bit parity field at the end of msg.
+func modesParity(msg []byte) uint32 {
+	n := len(msg)
+	return uint32(msg[n-3])<<16 | uint32(msg[n-2])<<8 | uint32(msg[n-1])
+}
+
+// decodeModeS decodes a 7- or 14-byte Mode S message.
+//
+// DF17/18 extended squitters carry their own address and are accepted when the
+// CRC checks out. DF4/5/20/21 overlay the address on the parity, so the
+// recovered address is returned and the caller must check it against aircraft
+// already heard via DF11/17/18 before trusting it.
+func decodeModeS(msg []byte) (*modesMessage, error) {
+	if len(msg) != 7 && len(msg) != 14 {
+		return nil, fmt.Errorf("modes: bad message length %d", len(msg))
+	}
+
+	m := &modesMessage{df: int(msg[0] >> 3)}
+	crc := modesCRC(msg)
+	parity := modesParity(msg)
+
+	switch m.df {
+	case 11: // all-call reply
+		// The parity is overlaid with the interrogator's II/SI code, so replies
+		// to other interrogators leave a residual in the low 7 bits.
+		if len(msg) != 7 || (crc^parity)&^0x7F != 0 {
+			return nil, fmt.Errorf("modes: DF11 parity mismatch")
+		}
+		m.icao24 = uint32(msg[1])<<16 | uint32(msg[2])<<8 | uint32(msg[3])
+
+	case 17, 18: // extended squitter (18 = non-transponder, CF 0 only)
+		if len(msg) != 14 || crc != parity {
+			return nil, fmt.Errorf("modes: DF%d parity mismatch", m.df)
+		}
+		if m.df == 18 && msg[0]&0x07 != 0 {
+			return nil, fmt.Errorf("modes: DF18 CF=%d not ICAO addressed", msg[0]&0x07)
+		}
+		m.icao24 = uint32(msg[1])<<16 | uint32(msg[2])<<8 | uint32(msg[3])
+		decodeExtendedSquitter(m, msg[4:11])
+
+	case 4, 20: // surveillance altitude reply
+		m.icao24 = crc ^ parity
+		m.altitude, m.hasAltitude = decodeAC13(int(msg[2]&0x1F)<<8 | int(msg[3]))
+		m.onGround = flightStatusOnGround(msg[0] & 0x07)
+
+	case 5, 21: // surveillance identity reply
+		m.icao24 = crc ^ parity
+		m.squawk = decodeSquawk(int(msg[2]&0x1F)<<8 | int(msg[3]))
+		m.onGround = flightStatusOnGround(msg[0] & 0x07)
+
+	default:
+		return nil, fmt.Errorf("modes: DF%d not supported", m.df)
+	}
+	return m, nil
+}
+
+// flightStatusOnGround reports whether a DF4/5/20/21 flight status says the
+// aircraft is on the ground: FS 1, or FS 3 with an alert.
+func flightStatusOnGround(fs byte) bool {
+	return fs == 1 || fs == 3
+}
+
+// decodeExtendedSquitter decodes the 56-bit ME field of a DF17/18 message.
+func decodeExtendedSquitter(m *modesMessage, me []byte) {
+	tc := int(me[0] >> 3)
+
+	switch {
+	case tc >= 1 && tc <= 4: // aircraft identification
+		var sb strings.Builder
+		bits := uint64(me[1])<<40 | uint64(me[2])<<32 | uint64(me[3])<<24 |
+			uint64(me[4])<<16 | uint64(me[5])<<8 | uint64(me[6])
+		for i := 7; i >= 0; i-- {
+			sb.WriteByte(modesCharset[(bits>>(uint(i)*6))&0x3F])
+		}
+		m.callsign = strings.TrimSpace(strings.ReplaceAll(sb.String(), "#", ""))
+		// TC 4..1 are category sets A..D; category 0 means no information
+		if ca := int(me[0] & 0x07); ca != 0 {
+			m.category = EmitterCategory(fmt.Sprintf("%c%d", 'A'+4-tc, ca))
+		}
+
+	case tc >= 5 && tc <= 8: // surface position
+		m.onGround = true
+
+	case (tc >= 9 && tc <= 18) || (tc >= 20 && tc <= 22): // airborne position
+		ac12 := int(me[1])<<4 | int(me[2]>>4)
+		if tc <= 18 {
+			m.altitude, m.hasAltitude = decodeAC12(ac12)
+		} else {
+			// GNSS height is in metres
+			m.geoAltitude, m.hasGeoAltitude = int(metresToFeet(float64(ac12))), ac12 != 0
+		}
+		m.hasCPR = true
+		m.cprOdd = me[2]&0x04 != 0
+		m.cprLat = int(me[2]&0x03)<<15 | int(me[3])<<7 | int(me[4]>>1)
+		m.cprLon = int(me[4]&0x01)<<16 | int(me[5])<<8 | int(me[6])
+
+	case tc == 19: // airborne velocity
+		decodeVelocity(m, me)
+	}
+}
+
+// decodeVelocity decodes TC 19 subtypes 1-2 (ground speed) and 3-4 (airspeed + heading).
+func decodeVelocity(m *modesMessage, me []byte) {
+	subtype := int(me[0] & 0x07)
+
+	switch subtype {
+	case 1, 2:
+		vew := (int(me[1]&0x03)<<8 | int(me[2])) - 1
+		vns := (int(me[3]&0x7F)<<3 | int(me[4]>>5)) - 1
+		if vew >= 0 && vns >= 0 {
+			if subtype == 2 { // supersonic
+				vew *= 4
+				vns *= 4
+			}
+			vx, vy := float64(vew), float64(vns)
+			if me[1]&0x04 != 0 {
+				vx = -vx // flying west
+			}
+			if me[3]&0x80 != 0 {
+				vy = -vy // flying south
+			}
+			m.groundspeed = int(math.Round(math.Hypot(vx, vy)))
+			trk := math.Atan2(vx, vy) * 180 / math.Pi
+			if trk < 0 {
+				trk += 360
+			}
+			m.track = int(trk)
+			m.hasVelocity = true
+		}
+
+	case 3, 4:
+		// Airspeed rather than ground speed — close enough for display.
+		if me[1]&0x04 != 0 {
+			hdg := float64(int(me[1]&0x03)<<8|int(me[2])) * 360 / 1024
+			as := (int(me[3]&0x7F)<<3 | int(me[4]>>5)) - 1
+			if as >= 0 {
+				if subtype == 4 {
+					as *= 4
+				}
+				m.groundspeed = as
+				m.track = int(hdg)
+				m.hasVelocity = true
+			}
+		}
+
+	default:
+		return
+	}
+
+	vr := (int(me[4]&0x07)<<6 | int(me[5]>>2)) - 1
+	if vr >= 0 {
+		vr *= 64
+		if me[4]&0x08 != 0 {
+			vr = -vr
+		}
+		m.vertRate = vr
+		m.hasVertRate = true
+	}
+}
+
+// decodeAC12 decodes the 12-bit altitude field of an airborne position message.
+// Only 25ft (Q=1) encoding is supported; Gillham-coded altitudes are reported as unavailable.
+func decodeAC12(ac12 int) (int, bool) {
+	if ac12 == 0 || ac12&0x10 == 0 {
+		return 0, false
+	}
+	n := (ac12&0xFE0)>>1 | ac12&0x0F
+	return n*25 - 1000, true
+}
+
+// decodeAC13 decodes the 13-bit altitude code of a DF4/20 reply.
+// Metric (M=1) and Gillham (Q=0) encodings are reported as unavailable.
+func decodeAC13(ac13 int) (int, bool) {
+	if ac13 == 0 || ac13&0x40 != 0 || ac13&0x10 == 0 {
+		return 0, false
+	}
+	n := (ac13&0x1F80)>>2 | (ac13&0x20)>>1 | ac13&0x0F
+	return n*25 - 1000, true
+}
+
+// decodeSquawk decodes the 13-bit identity code of a DF5/21 reply.
+// Bit order (MSB first): C1 A1 C2 A2 C4 A4 X B1 D1 B2 D2 B4 D4.
+func decodeSquawk(id13 int) string {
+	bit := func(n int) int { return (id13 >> n) & 1 }
+	a := bit(7)<<2 | bit(9)<<1 | bit(11)
+	b := bit(1)<<2 | bit(3)<<1 | bit(5)
+	c := bit(8)<<2 | bit(10)<<1 | bit(12)
+	d := bit(0)<<2 | bit(2)<<1 | bit(4)
+	return fmt.Sprintf("%d%d%d%d", a, b, c, d)
+}
+
+// ── CPR position decoding ──
+
+const (
+	cprNZ    = 15
+	cprScale = 131072.0 // 2^17
+)
+
+// cprNL returns the number of longitude zones at the given latitude.
+func cprNL(lat float64) int {
+	if lat < 0 {
+		lat = -lat
+	}
+	if lat == 0 {
+		return 59
+	}
+	if lat >= 87 {
+		return 1
+	}
+	a := 1 - math.Cos(math.Pi/(2*cprNZ))
+	b := math.Pow(math.Cos(math.Pi/180*lat), 2)
+	return int(math.Floor(2 * math.Pi / math.Acos(1-a/b)))
+}
+
+// cprMod is a floored modulo that is always non-negative for positive y.
+func cprMod(x, y float64) float64 {
+	return x - y*math.Floor(x/y)
+}
+
+// cprGlobal decodes an airborne position from an even/odd frame pair.
+// oddNewer selects which frame's latitude zone the result is based on.
+// Returns false if the pair straddles a longitude zone boundary.
+func cprGlobal(evenLat, evenLon, oddLat, oddLon int, oddNewer bool) (float64, float64, bool) {
+	latE := float64(evenLat) / cprScale
+	lonE := float64(evenLon) / cprScale
+	latO := float64(oddLat) / cprScale
+	lonO := float64(oddLon) / cprScale
+
+	dLatE := 360.0 / 60
+	dLatO := 360.0 / 59
+
+	j := math.Floor(59*latE - 60*latO + 0.5)
+	rlatE := dLatE * (cprMod(j, 60) + latE)
+	rlatO := dLatO * (cprMod(j, 59) + latO)
+	if rlatE >= 270 {
+		rlatE -= 360
+	}
+	if rlatO >= 270 {
+		rlatO -= 360
+	}
+	if cprNL(rlatE) != cprNL(rlatO) {
+		return 0, 0, false
+	}
+
+	lat, cprLon, nl := rlatE, lonE, cprNL(rlatE)
+	ni := nl
+	if oddNewer {
+		lat, cprLon = rlatO, lonO
+		ni = nl - 1
+	}
+	if ni < 1 {
+		ni = 1
+	}
+
+	m := math.Floor(lonE*float64(nl-1) - lonO*float64(nl) + 0.5)
+	lon := (360.0 / float64(ni)) * (cprMod(m, float64(ni)) + cprLon)
+	if lon >= 180 {
+		lon -= 360
+	}
+	return lat, lon, true
+}
+
+// cprLocal decodes a single airborne CPR frame relative to a reference
+// position, which must be within 180nm of the aircraft.
+func cprLocal(cprLat, cprLon int, odd bool, refLat, refLon float64) (float64, float64) {
+	i := 0.0
+	if odd {
+		i = 1
+	}
+	fLat := float64(cprLat) / cprScale
+	fLon := float64(cprLon) / cprScale
+
+	dLat := 360.0 / (60 - i)
+	j := math.Floor(refLat/dLat) + math.Floor(0.5+cprMod(refLat, dLat)/dLat-fLat)
+	lat := dLat * (j + fLat)
+
+	dLon := 360.0
+	if ni := float64(cprNL(lat)) - i; ni > 0 {
+		dLon = 360 / ni
+	}
+	m := math.Floor(refLon/dLon) + math.Floor(0.5+cprMod(refLon, dLon)/dLon-fLon)
+	lon := dLon * (m + fLon)
+	return lat, lon
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+// Test vectors are from "The 1090 Megahertz Riddle" (Junzi Sun).
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad test vector %q: %v", s, err)
+	}
+	return b
+}
+
+func TestModesCRC(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want uint32
+	}{
+		{"8D406B902015A678D4D220AA4BDA", 0xAA4BDA},
+		{"8D4840D6202CC371C32CE0576098", 0x576098},
+		{"8D40621D58C382D690C8AC2863A7", 0x2863A7},
+		{"8D485020994409940838175B284F", 0x5B284F},
+	}
+	for _, tt := range tests {
+		msg := mustHex(t, tt.msg)
+		if got := modesCRC(msg); got != tt.want {
+			t.Errorf("modesCRC(%s) = %06X, want %06X", tt.msg, got, tt.want)
+		}
+		if got := modesParity(msg); got != tt.want {
+			t.Errorf("modesParity(%s) = %06X, want %06X", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeModeSRejectsCorruptSquitter(t *testing.T) {
+	msg := mustHex(t, "8D406B902015A678D4D220AA4BDA")
+	msg[6] ^= 0x10
+	if _, err := decodeModeS(msg); err == nil {
+		t.Fatal("decodeModeS accepted a DF17 with a flipped bit")
+	}
+}
+
+func TestDecodeIdentification(t *testing.T) {
+	tests := []struct {
+		msg, icao, callsign string
+	}{
+		{"8D406B902015A678D4D220AA4BDA", "406b90", "EZY85MH"},
+		{"8D4840D6202CC371C32CE0576098", "4840d6", "KLM1023"},
+	}
+	for _, tt := range tests {
+		m, err := decodeModeS(mustHex(t, tt.msg))
+		if err != nil {
+			t.Fatalf("decodeModeS(%s): %v", tt.msg, err)
+		}
+		if m.icaoHex() != tt.icao || m.callsign != tt.callsign {
+			t.Errorf("decodeModeS(%s) = %s %q, want %s %q", tt.msg, m.icaoHex(), m.callsign, tt.icao, tt.callsign)
+		}
+	}
+}
+
+func TestDecodeAirbornePosition(t *testing.T) {
+	tests := []struct {
+		msg            string
+		odd            bool
+		cprLat, cprLon int
+	}{
+		{"8D40621D58C382D690C8AC2863A7", false, 93000, 51372},
+		{"8D40621D58C386435CC412692AD6", true, 74158, 50194},
+	}
+	for _, tt := range tests {
+		m, err := decodeModeS(mustHex(t, tt.msg))
+		if err != nil {
+			t.Fatalf("decodeModeS(%s): %v", tt.msg, err)
+		}
+		if !m.hasCPR || m.cprOdd != tt.odd || m.cprLat != tt.cprLat || m.cprLon != tt.cprLon {
+			t.Errorf("decodeModeS(%s) CPR = %v odd=%v %d,%d, want odd=%v %d,%d",
+				tt.msg, m.hasCPR, m.cprOdd, m.cprLat, m.cprLon, tt.odd, tt.cprLat, tt.cprLon)
+		}
+		if !m.hasAltitude || m.altitude != 38000 {
+			t.Errorf("decodeModeS(%s) altitude = %d (%v), want 38000", tt.msg, m.altitude, m.hasAltitude)
+		}
+	}
+}
+
+func TestDecodeVelocity(t *testing.T) {
+	m, err := decodeModeS(mustHex(t, "8D485020994409940838175B284F"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.hasVelocity || m.groundspeed != 159 || m.track != 182 {
+		t.Errorf("velocity = %d kt %d°, want 159 kt 182°", m.groundspeed, m.track)
+	}
+	if !m.hasVertRate || m.vertRate != -832 {
+		t.Errorf("vertical rate = %d, want -832", m.vertRate)
+	}
+}
+
+func TestCPRGlobal(t *testing.T) {
+	const wantLat, wantLon = 52.2572021484375, 3.91937255859375
+
+	// The even frame is the newer of the pair.
+	lat, lon, ok := cprGlobal(93000, 51372, 74158, 50194, false)
+	if !ok {
+		t.Fatal("cprGlobal: pair rejected")
+	}
+	if math.Abs(lat-wantLat) > 1e-9 || math.Abs(lon-wantLon) > 1e-9 {
+		t.Errorf("cprGlobal = %.6f,%.6f, want %.6f,%.6f", lat, lon, wantLat, wantLon)
+	}
+
+	// Based on the odd frame, it is the odd frame's position: latitude zone
+	// 8 of 59 and longitude zone 0 of NL-1 = 35.
+	oddLat, oddLon := 360.0/59*(8+74158/cprScale), 360.0/35*(50194/cprScale)
+	lat, lon, ok = cprGlobal(93000, 51372, 74158, 50194, true)
+	if !ok {
+		t.Fatal("cprGlobal (odd newer): pair rejected")
+	}
+	if math.Abs(lat-oddLat) > 1e-9 || math.Abs(lon-oddLon) > 1e-9 {
+		t.Errorf("cprGlobal (odd newer) = %.6f,%.6f, want %.6f,%.6f", lat, lon, oddLat, oddLon)
+	}
+}
+
+func TestCPRLocal(t *testing.T) {
+	lat, lon := cprLocal(93000, 51372, false, 52.258, 3.918)
+	if math.Abs(lat-52.2572021484375) > 1e-9 || math.Abs(lon-3.91937255859375) > 1e-9 {
+		t.Errorf("cprLocal = %.6f,%.6f, want 52.257202,3.919373", lat, lon)
+	}
+}
+
+func TestDecodeAllCallReply(t *testing.T) {
+	// DF11 parity is the CRC overlaid with the interrogator code: zero for
+	// replies to acquisition squitters, II/SI in the low 7 bits otherwise.
+	msg := []byte{0x5D, 0x48, 0x40, 0xD6, 0, 0, 0}
+	crc := modesCRC(msg)
+	for _, tt := range []struct {
+		residual uint32
+		ok       bool
+	}{{0, true}, {0x05, true}, {0x7F, true}, {0x80, false}, {0x010000, false}} {
+		p := crc ^ tt.residual
+		msg[4], msg[5], msg[6] = byte(p>>16), byte(p>>8), byte(p)
+		m, err := decodeModeS(msg)
+		if (err == nil) != tt.ok {
+			t.Errorf("residual %06X: err = %v, want ok=%v", tt.residual, err, tt.ok)
+			continue
+		}
+		if err == nil && m.icaoHex() != "4840d6" {
+			t.Errorf("residual %06X: address %s, want 4840d6", tt.residual, m.icaoHex())
+		}
+	}
+}
+
+func TestDecodeFlightStatus(t *testing.T) {
+	for fs, ground := range map[byte]bool{0: false, 1: true, 2: false, 3: true, 4: false, 5: false} {
+		for _, df := range []byte{4, 5, 20, 21} {
+			n := 7
+			if df >= 20 {
+				n = 14
+			}
+			msg := make([]byte, n)
+			msg[0] = df<<3 | fs
+			m, err := decodeModeS(msg)
+			if err != nil {
+				t.Fatalf("DF%d FS=%d: %v", df, fs, err)
+			}
+			if m.onGround != ground {
+				t.Errorf("DF%d FS=%d: onGround = %v, want %v", df, fs, m.onGround, ground)
+			}
+		}
+	}
+}
@@ -1,72 +1,151 @@
 package provider
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"path/filepath"
 	"sort"
+	"sync"
 	"time"
 )
 
-// providerEntry pairs a provider with its optional rate limit.
+// providerEntry pairs a provider with its optional rate limit and health.
 type providerEntry struct {
 	provider FlightProvider
 	limit    *RateLimit // nil = unlimited
+	health   *providerHealth
 }
 
-// MultiProvider tries multiple FlightProviders, selecting by available rate limit capacity.
+// MultiProvider tries multiple FlightProviders, ranked by rate limit capacity
+// and health. Providers that keep failing are skipped by a circuit breaker.
 type MultiProvider struct {
 	entries []providerEntry
 	// activeIdx tracks which provider last succeeded for position polling.
 	activeIdx int
+	// validator rejects positions that contradict the flight's last one,
+	// whichever provider reported it.
+	validator *positionValidator
+
+	// Merge queries every provider with remaining capacity and fuses their
+	// results instead of returning the first success. Fields are taken from
+	// providers in the order they were passed to NewMultiProvider.
+	Merge bool
 }
 
 // NewMultiProvider creates a provider that selects from the given providers based on rate limits.
 func NewMultiProvider(providers ...FlightProvider) *MultiProvider {
 	entries := make([]providerEntry, len(providers))
 	for i, p := range providers {
-		entries[i] = providerEntry{provider: p}
+		entries[i] = providerEntry{provider: p, health: newProviderHealth(p.Name())}
 	}
-	return &MultiProvider{entries: entries}
+	return &MultiProvider{entries: entries, validator: newPositionValidator()}
 }
 
 // SetRateLimit configures a rate limit for a provider by name.
 func (m *MultiProvider) SetRateLimit(providerName string, maxReqs int, window time.Duration) {
+	m.setLimit(providerName, NewRateLimit(maxReqs, window))
+}
+
+// SetMonthlyRateLimit configures a calendar-month quota for a provider by name.
+func (m *MultiProvider) SetMonthlyRateLimit(providerName string, maxReqs int) {
+	m.setLimit(providerName, NewMonthlyRateLimit(maxReqs))
+}
+
+func (m *MultiProvider) setLimit(providerName string, lim *RateLimit) {
 	for i := range m.entries {
 		if m.entries[i].provider.Name() == providerName {
-			m.entries[i].limit = NewRateLimit(maxReqs, window)
-			log.Printf("[ratelimit] %s: %d requests per %v", providerName, maxReqs, window)
+			m.entries[i].limit = lim
+			log.Printf("[ratelimit] %s: %v", providerName, lim)
 			return
 		}
 	}
 }
 
+// PersistRateLimits saves every configured rate limit window under dir and
+// restores any windows saved by a previous run. Call after the limits are set.
+// A provider whose saved window can't be read starts from an empty one and is
+// still saved; the errors are logged and returned joined.
+func (m *MultiProvider) PersistRateLimits(dir string) error {
+	var errs []error
+	for _, e := range m.entries {
+		if e.limit == nil {
+			continue
+		}
+		path := filepath.Join(dir, e.provider.Name()+".json")
+		if err := e.limit.Persist(path); err != nil {
+			log.Printf("[ratelimit] %s: %v", e.provider.Name(), err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Printf("[ratelimit] %s: restored %d requests in current window", e.provider.Name(), e.limit.Used())
+	}
+	return errors.Join(errs...)
+}
+
+// FlushRateLimits writes any rate limit windows changed since they were
+// last saved. Call it before exiting; the errors are returned joined.
+func (m *MultiProvider) FlushRateLimits() error {
+	var errs []error
+	for _, e := range m.entries {
+		if e.limit == nil {
+			continue
+		}
+		if err := e.limit.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.provider.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (m *MultiProvider) Name() string {
 	return "multi"
 }
 
-// sortedByCapacity returns provider indices sorted by descending rate limit capacity.
-// Providers with no rate limit (unlimited) are scored at 100%.
-// Providers at 0% capacity are excluded.
-func (m *MultiProvider) sortedByCapacity() []int {
+// capacity returns the remaining rate limit capacity of the provider at idx
+// for requests of class p. Providers with no rate limit (unlimited) are at 100%.
+func (m *MultiProvider) capacity(idx int, p Priority) float64 {
+	if lim := m.entries[idx].limit; lim != nil {
+		return lim.CapacityFor(p)
+	}
+	return 1.0
+}
+
+// ranked returns provider indices sorted by descending score, where score is
+// the rate limit capacity left to class p weighted by health. Providers at 0%
+// capacity or with an open circuit are excluded, except that a provider due
+// for a half-open probe is ranked first so a recovered provider is noticed
+// promptly.
+func (m *MultiProvider) ranked(p Priority) []int {
 	type scored struct {
-		idx      int
-		capacity float64
+		idx   int
+		score float64
+		probe bool
 	}
 
 	var candidates []scored
 	for i, e := range m.entries {
-		cap := 1.0 // unlimited
-		if e.limit != nil {
-			cap = e.limit.CapacityPct()
-		}
+		cap := m.capacity(i, p)
 		if cap <= 0 {
 			continue // exhausted, skip
 		}
-		candidates = append(candidates, scored{idx: i, capacity: cap})
+		if e.health.probeDue() {
+			candidates = append(candidates, scored{idx: i, probe: true})
+			continue
+		}
+		score := cap * e.health.score()
+		if score <= 0 {
+			continue // circuit open
+		}
+		candidates = append(candidates, scored{idx: i, score: score})
 	}
 
-	sort.Slice(candidates, func(a, b int) bool {
-		return candidates[a].capacity > candidates[b].capacity
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if candidates[a].probe != candidates[b].probe {
+			return candidates[a].probe
+		}
+		return candidates[a].score > candidates[b].score
 	})
 
 	indices := make([]int, len(candidates))
@@ -76,48 +155,141 @@ func (m *MultiProvider) sortedByCapacity() []int {
 	return indices
 }
 
-// canUse returns true if the provider at the given index is within its rate limit.
-func (m *MultiProvider) canUse(idx int) bool {
+// canUse returns true if the provider at the given index has rate limit
+// capacity left for a request of class p.
+func (m *MultiProvider) canUse(idx int, p Priority) bool {
 	lim := m.entries[idx].limit
-	return lim == nil || lim.Allow()
+	return lim == nil || lim.AllowPriority(p)
+}
+
+// skipReason returns why the provider at idx can't take a request of class p
+// right now, or "" if it can. A provider that passes may be admitted as a
+// half-open probe or charged against its budget, so a request must follow.
+func (m *MultiProvider) skipReason(ctx context.Context, idx int, p Priority) string {
+	if !m.canUse(idx, p) {
+		return "rate-limited for " + p.String()
+	}
+	if !m.entries[idx].health.allow() {
+		return "circuit open"
+	}
+	if sl, ok := unwrapAs[spendLimiter](m.entries[idx].provider); ok {
+		if err := sl.checkSpend(ctx, p); err != nil {
+			m.entries[idx].health.observe(0, err) // releases a probe; not a failure
+			var limited *RateLimitedError
+			if errors.As(err, &limited) {
+				return "over " + limited.Reason
+			}
+			return err.Error()
+		}
+	}
+	return ""
 }
 
-// recordUse records a request for the provider at the given index.
-func (m *MultiProvider) recordUse(idx int) {
+// quotaContext returns ctx wired to re-sync the rate limit of the provider at
+// idx from the quota signals its server reports, to refund the request of
+// class p if a cache answers it, and to count any further requests the call
+// makes.
+func (m *MultiProvider) quotaContext(ctx context.Context, idx int, p Priority) context.Context {
+	name := m.entries[idx].provider.Name()
+	lim := m.entries[idx].limit
+	ctx = withCacheHitReporter(ctx, func() {
+		if lim != nil {
+			lim.Refund(p)
+		}
+	})
+	ctx = withExtraRequestReporter(ctx, func() {
+		if lim != nil {
+			lim.RecordPriority(p)
+		}
+	})
+	return withQuotaReporter(ctx, func(q QuotaSignal) {
+		if lim == nil {
+			log.Printf("[ratelimit] %s: ignoring server quota signal, no limit configured", name)
+			return
+		}
+		if q.Remaining >= 0 {
+			lim.Sync(q.Remaining)
+		}
+		if q.RetryAfter > 0 {
+			lim.BlockFor(q.RetryAfter)
+			log.Printf("[ratelimit] %s: server requested backoff for %v", name, q.RetryAfter)
+		}
+	})
+}
+
+// observe records the outcome of a request to the provider at idx.
+func (m *MultiProvider) observe(idx int, start time.Time, err error) {
+	m.entries[idx].health.observe(time.Since(start), err)
+}
+
+// Health returns a snapshot of every provider's health and quota.
+func (m *MultiProvider) Health() []ProviderHealth {
+	out := make([]ProviderHealth, len(m.entries))
+	for i, e := range m.entries {
+		out[i] = e.health.snapshot()
+		out[i].Capacity = m.capacity(i, PriorityFeatured)
+		out[i].Remaining, out[i].DiscoveryRemaining = -1, -1
+		if e.limit != nil {
+			out[i].Remaining = e.limit.Remaining()
+			out[i].DiscoveryRemaining = e.limit.RemainingFor(PriorityDiscovery)
+			out[i].Window = e.limit.Horizon()
+		}
+		out[i].Score = out[i].Capacity * e.health.score()
+	}
+	return out
+}
+
+// recordUse records a request of class p for the provider at the given index.
+func (m *MultiProvider) recordUse(idx int, p Priority) {
 	if lim := m.entries[idx].limit; lim != nil {
-		lim.Record()
+		lim.RecordPriority(p)
 	}
 }
 
-// logRateStatus logs current rate limit status for all providers.
+// logRateStatus logs current rate limit and circuit status for all providers.
 func (m *MultiProvider) logRateStatus() {
 	for _, e := range m.entries {
 		if e.limit != nil {
 			log.Printf("[ratelimit] %s: %d remaining (%.0f%% capacity)",
 				e.provider.Name(), e.limit.Remaining(), e.limit.CapacityPct()*100)
 		}
+		if h := e.health.snapshot(); h.State != BreakerClosed {
+			log.Printf("[health] %s: circuit %s, retry in %v (last error: %s)",
+				h.Name, h.State, h.RetryIn.Round(time.Second), h.LastError)
+		}
 	}
 }
 
-// GetFlightsNear tries providers sorted by capacity until one returns results.
-func (m *MultiProvider) GetFlightsNear(airportICAO string, direction FlightDirection) ([]Flight, error) {
-	order := m.sortedByCapacity()
+// GetFlightsNear tries providers in ranked order until one returns results.
+// In Merge mode it queries all providers with capacity and fuses the results.
+func (m *MultiProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	if m.Merge {
+		return m.getFlightsMerged(ctx, airportICAO, direction)
+	}
+	prio := priorityFrom(ctx, PriorityDiscovery)
+
+	order := m.ranked(prio)
 	if len(order) == 0 {
 		m.logRateStatus()
-		return nil, fmt.Errorf("all providers rate-limited, please wait")
+		return nil, fmt.Errorf("all providers rate-limited or failing, please wait")
 	}
 
 	var lastErr error
 	for _, i := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		p := m.entries[i].provider
 
-		if !m.canUse(i) {
-			log.Printf("[provider] %s rate-limited, skipping", p.Name())
+		if why := m.skipReason(ctx, i, prio); why != "" {
+			log.Printf("[provider] %s %s, skipping", p.Name(), why)
 			continue
 		}
 
-		m.recordUse(i)
-		flights, err := p.GetFlightsNear(airportICAO, direction)
+		m.recordUse(i, prio)
+		start := time.Now()
+		flights, err := p.GetFlightsNear(m.quotaContext(ctx, i, prio), airportICAO, direction)
+		m.observe(i, start, err)
 		if err != nil {
 			log.Printf("[provider] %s failed for GetFlightsNear: %v", p.Name(), err)
 			lastErr = err
@@ -145,6 +317,80 @@ func (m *MultiProvider) GetFlightsNear(airportICAO string, direction FlightDirec
 	return nil, nil
 }
 
+// getFlightsMerged queries every provider within its rate limit concurrently
+// and fuses the results into one Flight per aircraft.
+func (m *MultiProvider) getFlightsMerged(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	prio := priorityFrom(ctx, PriorityDiscovery)
+	results := make([]sourceFlights, len(m.entries))
+	errs := make([]error, len(m.entries))
+
+	var wg sync.WaitGroup
+	for i := range m.entries {
+		p := m.entries[i].provider
+		if why := m.skipReason(ctx, i, prio); why != "" {
+			log.Printf("[provider] %s %s, skipping", p.Name(), why)
+			continue
+		}
+		m.recordUse(i, prio)
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			flights, err := p.GetFlightsNear(m.quotaContext(ctx, i, prio), airportICAO, direction)
+			m.observe(i, start, err)
+			results[i] = sourceFlights{provider: p.Name(), flights: flights}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keep configured order so field priority is stable between ticks.
+	var ok []sourceFlights
+	var lastErr error
+	for i, res := range results {
+		if errs[i] != nil {
+			log.Printf("[provider] %s failed for GetFlightsNear: %v", res.provider, errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		if len(res.flights) == 0 {
+			continue
+		}
+		if len(ok) == 0 {
+			m.activeIdx = i
+		}
+		ok = append(ok, res)
+	}
+
+	if len(ok) == 0 {
+		m.logRateStatus()
+		if lastErr != nil {
+			return nil, fmt.Errorf("all providers failed, last error: %w", lastErr)
+		}
+		return nil, nil
+	}
+
+	flights := fuseFlights(ok)
+	log.Printf("[provider] merged %d flights from %d providers", len(flights), len(ok))
+	return flights, nil
+}
+
+// flightFor returns the flight as the named provider knows it: fused flights
+// carry each provider's own FlightID in SourceIDs.
+func flightFor(flight *Flight, providerName string) *Flight {
+	id, ok := flight.SourceIDs[providerName]
+	if !ok || id == flight.FlightID {
+		return flight
+	}
+	f := *flight
+	f.FlightID = id
+	return &f
+}
+
 // providerIdxByName returns the index of a provider by its name, or -1 if not found.
 func (m *MultiProvider) providerIdxByName(name string) int {
 	for i, e := range m.entries {
@@ -156,51 +402,72 @@ func (m *MultiProvider) providerIdxByName(name string) int {
 }
 
 // GetFlightPosition uses the flight's source provider for position polling.
-// Only falls back to other providers if the source provider is rate-limited.
-func (m *MultiProvider) GetFlightPosition(flight *Flight) (*FlightPosition, error) {
+// Only falls back to other providers if the source provider fails, is
+// rate-limited or has an open circuit.
+func (m *MultiProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	prio := priorityFrom(ctx, PriorityFeatured)
+
 	// Determine which provider to use — prefer the source (discovery) provider
 	srcIdx := m.providerIdxByName(flight.SourceProvider)
 	if srcIdx < 0 {
 		srcIdx = m.activeIdx // fallback to active if source unknown
 	}
 
-	// Try the source provider if it has capacity
-	if srcIdx < len(m.entries) && m.canUse(srcIdx) {
-		m.recordUse(srcIdx)
-		pos, err := m.entries[srcIdx].provider.GetFlightPosition(flight)
+	// Try the source provider if it has capacity and is healthy
+	why := ""
+	if srcIdx < len(m.entries) {
+		why = m.skipReason(ctx, srcIdx, prio)
+	}
+	if srcIdx < len(m.entries) && why == "" {
+		m.recordUse(srcIdx, prio)
+		src := m.entries[srcIdx].provider
+		start := time.Now()
+		pos, err := src.GetFlightPosition(m.quotaContext(ctx, srcIdx, prio), flightFor(flight, src.Name()))
+		m.observe(srcIdx, start, err)
 		if err == nil && pos != nil {
-			return pos, nil
+			if m.validPosition(srcIdx, flight, pos, BoundingBox{}) == nil {
+				return pos, nil
+			}
 		}
 		if err != nil {
 			log.Printf("[provider] %s failed for GetFlightPosition: %v",
 				m.entries[srcIdx].provider.Name(), err)
 		}
 	} else if srcIdx < len(m.entries) {
-		log.Printf("[provider] %s (source) rate-limited, falling back to others",
-			m.entries[srcIdx].provider.Name())
+		log.Printf("[provider] %s (source) %s, falling back to others",
+			m.entries[srcIdx].provider.Name(), why)
 	}
 
-	// Source failed or rate-limited — fall back to others sorted by capacity
-	order := m.sortedByCapacity()
+	// Source failed or unavailable — fall back to others in ranked order
+	order := m.ranked(prio)
 	var lastErr error
 	for _, i := range order {
 		if i == srcIdx {
 			continue // already tried
 		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		p := m.entries[i].provider
-		if !m.canUse(i) {
+		if m.skipReason(ctx, i, prio) != "" {
 			continue
 		}
 
 		log.Printf("[provider] falling back to %s for position (source was %s)",
 			p.Name(), flight.SourceProvider)
-		m.recordUse(i)
-		pos, err := p.GetFlightPosition(flight)
+		m.recordUse(i, prio)
+		start := time.Now()
+		pos, err := p.GetFlightPosition(m.quotaContext(ctx, i, prio), flightFor(flight, p.Name()))
+		m.observe(i, start, err)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 		if pos != nil {
+			if err := m.validPosition(i, flight, pos, BoundingBox{}); err != nil {
+				lastErr = err
+				continue
+			}
 			return pos, nil
 		}
 	}
@@ -209,5 +476,153 @@ func (m *MultiProvider) GetFlightPosition(flight *Flight) (*FlightPosition, erro
 	if lastErr != nil {
 		return nil, fmt.Errorf("all providers failed for position, last error: %w", lastErr)
 	}
-	return nil, fmt.Errorf("no position available (providers may be rate-limited)")
+	return nil, fmt.Errorf("no position available (providers may be rate-limited or failing)")
+}
+
+// GetPositionsNear returns bulk positions from the best-ranked provider that
+// supports them. It runs as PriorityBackground unless ctx says otherwise:
+// filling in the rest of the radar must not starve discovery or the featured
+// flight. A snapshot a provider already has cached is used first, whatever
+// its quota, since it costs nothing.
+func (m *MultiProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	prio := priorityFrom(ctx, PriorityBackground)
+
+	for i, e := range m.entries {
+		if snap, ok := cachedPositionsNear(e.provider, airportICAO); ok {
+			return m.validSnapshot(i, snap), nil
+		}
+	}
+
+	var lastErr error = errNoBulkPositions
+	for _, i := range m.ranked(prio) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p := m.entries[i].provider
+		bp, ok := bulkPositions(p)
+		if !ok || m.skipReason(ctx, i, prio) != "" {
+			continue
+		}
+
+		m.recordUse(i, prio)
+		start := time.Now()
+		snap, err := bp.GetPositionsNear(m.quotaContext(ctx, i, prio), airportICAO)
+		m.observe(i, start, err)
+		if err != nil {
+			log.Printf("[provider] %s failed for GetPositionsNear: %v", p.Name(), err)
+			lastErr = err
+			continue
+		}
+		return m.validSnapshot(i, snap), nil
+	}
+	return nil, lastErr
+}
+
+// GetRoute asks the providers that can look up routes, as PriorityBackground
+// unless ctx says otherwise. Providers that bill per request are asked after
+// the free ones. The error wraps ErrNotFound only if every route provider
+// answered and none knew the route; if any was skipped or failed, the route
+// may still be found later.
+func (m *MultiProvider) GetRoute(ctx context.Context, flight *Flight) (*Route, error) {
+	prio := priorityFrom(ctx, PriorityBackground)
+
+	// Ranked providers first, then those ranking excludes, so an exhausted
+	// provider is reported as skipped rather than ignored.
+	order := m.ranked(prio)
+	ranked := make(map[int]bool, len(order))
+	for _, i := range order {
+		ranked[i] = true
+	}
+	for i := range m.entries {
+		if !ranked[i] {
+			order = append(order, i)
+		}
+	}
+	billed := func(i int) bool {
+		_, ok := unwrapAs[spendLimiter](m.entries[i].provider)
+		return ok
+	}
+	sort.SliceStable(order, func(a, b int) bool { return !billed(order[a]) && billed(order[b]) })
+
+	var lastErr error
+	for _, i := range order {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		p := m.entries[i].provider
+		rp, ok := unwrapAs[RouteProvider](p)
+		if !ok {
+			continue
+		}
+		if why := m.skipReason(ctx, i, prio); why != "" {
+			lastErr = fmt.Errorf("%s: %s", p.Name(), why)
+			continue
+		}
+
+		m.recordUse(i, prio)
+		start := time.Now()
+		route, err := rp.GetRoute(m.quotaContext(ctx, i, prio), flight)
+		m.observe(i, start, err)
+		if err == nil && route != nil {
+			return route, nil
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("[provider] %s failed for GetRoute: %v", p.Name(), err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("route for %q: %w", callsignOf(flight), lastErr)
+	}
+	return nil, fmt.Errorf("route for %q: %w", callsignOf(flight), ErrNotFound)
+}
+
+// validPosition checks a position the provider at idx reported for flight,
+// from a query of box if it came from a bulk query.
+func (m *MultiProvider) validPosition(idx int, flight *Flight, pos *FlightPosition, box BoundingBox) error {
+	err := m.validator.check(flight, pos, box)
+	var again rejectedBefore
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &again):
+		// Counted and logged when first seen
+		return fmt.Errorf("%s: implausible position: %w", m.entries[idx].provider.Name(), err)
+	}
+	return m.rejectPosition(idx, flight, err)
+}
+
+// rejectPosition logs a rejected position, counts it against the provider at
+// idx and returns the reason as an error.
+func (m *MultiProvider) rejectPosition(idx int, flight *Flight, reason error) error {
+	name := m.entries[idx].provider.Name()
+	n := m.entries[idx].health.reject()
+	log.Printf("[validate] %s: rejected position for %s (%d rejected): %v", name, flight.DisplayIdent(), n, reason)
+	return fmt.Errorf("%s: implausible position: %w", name, reason)
+}
+
+// validSnapshot returns snap without the positions that fail validation or
+// lie outside the area queried. Snapshots may be shared, so a filtered copy
+// is returned rather than snap being modified.
+func (m *MultiProvider) validSnapshot(idx int, snap *AreaSnapshot) *AreaSnapshot {
+	m.validator.prune(time.Now())
+	var out *AreaSnapshot
+	for j := range snap.Positions {
+		flight, pos := &snap.Flights[j], &snap.Positions[j]
+		err := m.validPosition(idx, flight, pos, snap.Box)
+		switch {
+		case err != nil && out == nil:
+			// First rejection: copy what was kept so far
+			out = &AreaSnapshot{Box: snap.Box, FetchedAt: snap.FetchedAt}
+			out.Flights = append(out.Flights, snap.Flights[:j]...)
+			out.Positions = append(out.Positions, snap.Positions[:j]...)
+		case err == nil && out != nil:
+			out.Flights = append(out.Flights, *flight)
+			out.Positions = append(out.Positions, *pos)
+		}
+	}
+	if out == nil {
+		return snap
+	}
+	return out
 }
@@ -1,7 +1,9 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,17 +11,14 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/subham/flighttracker/internal/airlines"
+	"github.com/subham/flighttracker/internal/station"
 )
 
 const openskyBaseURL = "https://opensky-network.org/api"
 const openskyTokenURL = "https://auth.opensky-network.org/auth/realms/opensky-network/protocol/openid-connect/token"
 
-// SFO coordinates for bounding box.
-const (
-	sfoLatOS = 37.6213
-	sfoLonOS = -122.3790
-)
-
 // OpenSkyProvider implements FlightProvider using the OpenSky Network REST API.
 // Supports OAuth2 client credentials flow (required for accounts created since mid-March 2025).
 type OpenSkyProvider struct {
@@ -27,6 +26,10 @@ type OpenSkyProvider struct {
 	clientSecret string // OAuth2 client_secret (env: OPENSKY_PASS)
 	httpClient   *http.Client
 
+	// Home is the airport the wide callsign search box for position polls is
+	// centred on. Defaults to the default station.
+	Home string
+
 	// OAuth2 token cache
 	mu          sync.Mutex
 	accessToken string
@@ -40,6 +43,7 @@ func NewOpenSkyProvider(clientID, clientSecret string) *OpenSkyProvider {
 	return &OpenSkyProvider{
 		clientID:     clientID,
 		clientSecret: clientSecret,
+		Home:         station.Default().Code,
 		httpClient: &http.Client{
 			Timeout: 15 * time.Second,
 		},
@@ -49,7 +53,7 @@ func NewOpenSkyProvider(clientID, clientSecret string) *OpenSkyProvider {
 func (o *OpenSkyProvider) Name() string { return "opensky" }
 
 // getToken returns a valid OAuth2 access token, fetching or refreshing as needed.
-func (o *OpenSkyProvider) getToken() (string, error) {
+func (o *OpenSkyProvider) getToken(ctx context.Context) (string, error) {
 	if o.clientID == "" {
 		return "", nil // anonymous access
 	}
@@ -69,7 +73,13 @@ func (o *OpenSkyProvider) getToken() (string, error) {
 		"client_secret": {o.clientSecret},
 	}
 
-	resp, err := o.httpClient.Post(openskyTokenURL, "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, openskyTokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("opensky oauth: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := o.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("opensky oauth: request failed: %w", err)
 	}
@@ -97,7 +107,7 @@ func (o *OpenSkyProvider) getToken() (string, error) {
 
 // setAuth adds authentication to a request (Bearer token or anonymous).
 func (o *OpenSkyProvider) setAuth(req *http.Request) error {
-	token, err := o.getToken()
+	token, err := o.getToken(req.Context())
 	if err != nil {
 		return err
 	}
@@ -107,159 +117,212 @@ func (o *OpenSkyProvider) setAuth(req *http.Request) error {
 	return nil
 }
 
-// GetFlightsNear returns airborne flights in a bounding box around the airport.
-func (o *OpenSkyProvider) GetFlightsNear(airportICAO string, direction FlightDirection) ([]Flight, error) {
-	// Use a ~60nm bounding box around the airport.
+// NearBox returns the ~60nm box GetFlightsNear searches around the airport.
+func (o *OpenSkyProvider) NearBox(airportICAO string) BoundingBox {
 	lat, lon := airportCoords(airportICAO)
-	delta := 1.0 // ~1 degree ≈ 60nm — keeps results close to the airport
-	lamin := lat - delta
-	lamax := lat + delta
-	lomin := lon - delta
-	lomax := lon + delta
-
-	apiURL := fmt.Sprintf("%s/states/all?lamin=%.4f&lomin=%.4f&lamax=%.4f&lomax=%.4f",
-		openskyBaseURL, lamin, lomin, lamax, lomax)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("opensky: %w", err)
-	}
-	req.Header.Set("User-Agent", "SFOFlightTracker/1.0")
-	if err := o.setAuth(req); err != nil {
-		return nil, fmt.Errorf("opensky: auth failed: %w", err)
-	}
+	return boxAround(lat, lon, 1.0) // ~1 degree ≈ 60nm — keeps results close to the airport
+}
 
-	resp, err := o.httpClient.Do(req)
+// GetFlightsNear returns airborne flights in a bounding box around the airport.
+func (o *OpenSkyProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	snap, err := o.GetArea(ctx, o.NearBox(airportICAO))
 	if err != nil {
-		return nil, fmt.Errorf("opensky: request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return snap.Airborne(), nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("opensky: HTTP %d", resp.StatusCode)
-	}
+// GetPositionsNear returns every aircraft in the GetFlightsNear box, with
+// positions. Behind a CachingProvider this is the discovery snapshot.
+func (o *OpenSkyProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	return o.GetArea(ctx, o.NearBox(airportICAO))
+}
 
-	var raw openskyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("opensky: decode error: %w", err)
+// GetArea returns every state vector inside box.
+func (o *OpenSkyProvider) GetArea(ctx context.Context, box BoundingBox) (*AreaSnapshot, error) {
+	raw, err := o.fetchStates(ctx, fmt.Sprintf("lamin=%.4f&lomin=%.4f&lamax=%.4f&lomax=%.4f",
+		box.LatMin, box.LonMin, box.LatMax, box.LonMax), openskyCredits(box))
+	if err != nil {
+		return nil, err
 	}
 
-	var flights []Flight
+	snap := &AreaSnapshot{Box: box, FetchedAt: time.Now()}
 	for _, s := range raw.States {
-		f := stateToFlight(s)
-		if f.IsAirborne && f.Ident != "" {
-			flights = append(flights, f)
-		}
+		snap.Flights = append(snap.Flights, stateToFlight(s))
+		snap.Positions = append(snap.Positions, stateToPosition(s))
 	}
-	return flights, nil
+	return snap, nil
 }
 
 // GetFlightPosition returns position for a flight using callsign or ICAO24 hex.
 // When called cross-provider, the FlightID may not be an ICAO24 hex, so we
-// fall back to searching by callsign in a bounding box around SFO.
-func (o *OpenSkyProvider) GetFlightPosition(flight *Flight) (*FlightPosition, error) {
-	callsign := flight.Ident
-	if callsign == "" {
-		callsign = flight.IdentICAO
-	}
-
-	// Try ICAO24 lookup first if FlightID looks like a hex address (6-char hex)
-	if isHexAddr(flight.FlightID) {
-		pos, err := o.getPositionByICAO24(flight.FlightID)
+// fall back to searching by callsign in a bounding box around Home.
+func (o *OpenSkyProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	// Try ICAO24 lookup first if the transponder address is known
+	if hex := flight.TransponderHex(); hex != "" {
+		pos, err := o.getPositionByICAO24(ctx, hex)
 		if err == nil {
 			return pos, nil
 		}
 		// Fall through to callsign search
 	}
 
-	// Search by callsign in a wide area around SFO
-	delta := 5.0 // wider box for position polling
-	apiURL := fmt.Sprintf("%s/states/all?lamin=%.4f&lomin=%.4f&lamax=%.4f&lomax=%.4f",
-		openskyBaseURL, sfoLatOS-delta, sfoLonOS-delta, sfoLatOS+delta, sfoLonOS+delta)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
+	// Search by callsign in a wide area around the home airport
+	lat, lon := airportCoords(o.Home)
+	snap, err := o.GetArea(ctx, boxAround(lat, lon, 5.0)) // wider box for position polling
 	if err != nil {
-		return nil, fmt.Errorf("opensky: %w", err)
+		return nil, err
 	}
-	req.Header.Set("User-Agent", "SFOFlightTracker/1.0")
-	if err := o.setAuth(req); err != nil {
-		return nil, fmt.Errorf("opensky: auth failed: %w", err)
+	if pos, ok := snap.Find(flight); ok {
+		return pos, nil
 	}
 
-	resp, err := o.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("opensky: request failed: %w", err)
+	callsign := flight.Ident
+	if callsign == "" {
+		callsign = flight.IdentICAO
 	}
-	defer resp.Body.Close()
+	return nil, fmt.Errorf("opensky: %q not in area: %w", callsign, ErrNotFound)
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("opensky: HTTP %d", resp.StatusCode)
+// getPositionByICAO24 looks up a single aircraft by its ICAO24 transponder hex.
+func (o *OpenSkyProvider) getPositionByICAO24(ctx context.Context, icao24 string) (*FlightPosition, error) {
+	raw, err := o.fetchStates(ctx, "icao24="+icao24, openskyGlobalCredits)
+	if err != nil {
+		return nil, err
 	}
 
-	var raw openskyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("opensky: decode error: %w", err)
+	if len(raw.States) == 0 {
+		return nil, fmt.Errorf("opensky: ICAO24 %s: %w", icao24, ErrNotFound)
 	}
 
-	// Find the matching callsign
-	for _, s := range raw.States {
-		f := stateToFlight(s)
-		if strings.EqualFold(f.Ident, callsign) || strings.EqualFold(f.IdentICAO, callsign) {
-			pos := stateToPosition(s)
-			return &pos, nil
-		}
-	}
+	pos := stateToPosition(raw.States[0])
+	return &pos, nil
+}
 
-	return nil, fmt.Errorf("opensky: aircraft %q not found in area", callsign)
+// openskyGlobalCredits is what a /states/all query without a bounding box
+// costs.
+const openskyGlobalCredits = 4
+
+// openskyCredits returns what a /states/all query over box costs. OpenSky's
+// daily quota is counted in credits, not requests, and larger areas cost
+// more.
+func openskyCredits(box BoundingBox) int {
+	switch area := (box.LatMax - box.LatMin) * (box.LonMax - box.LonMin); {
+	case area <= 25:
+		return 1
+	case area <= 100:
+		return 2
+	case area <= 400:
+		return 3
+	}
+	return openskyGlobalCredits
 }
 
-// getPositionByICAO24 looks up a single aircraft by its ICAO24 transponder hex.
-func (o *OpenSkyProvider) getPositionByICAO24(icao24 string) (*FlightPosition, error) {
-	apiURL := fmt.Sprintf("%s/states/all?icao24=%s", openskyBaseURL, icao24)
+// fetchStates calls /states/all with the given query string, asking for the
+// extended state vector so it includes the emitter category. credits is what
+// the query costs; everything past the first is reported as extra requests,
+// so the rate limit counts credits like the X-Rate-Limit-Remaining header.
+func (o *OpenSkyProvider) fetchStates(ctx context.Context, query string, credits int) (*openskyResponse, error) {
+	for i := 1; i < credits; i++ {
+		reportExtraRequest(ctx)
+	}
+	var raw openskyResponse
+	if err := o.getJSON(ctx, "/states/all?extended=1&"+query, &raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+// getJSON calls an API path (with its query string) and decodes the response
+// into dest. A 404, which OpenSky returns for empty results, wraps ErrNotFound.
+func (o *OpenSkyProvider) getJSON(ctx context.Context, path string, dest any) error {
+	apiURL := openskyBaseURL + path
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("opensky: %w", err)
+		return fmt.Errorf("opensky: %w", err)
 	}
 	req.Header.Set("User-Agent", "SFOFlightTracker/1.0")
 	if err := o.setAuth(req); err != nil {
-		return nil, fmt.Errorf("opensky: auth failed: %w", err)
+		return fmt.Errorf("opensky: auth failed: %w", err)
 	}
 
 	resp, err := o.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("opensky: request failed: %w", err)
+		return fmt.Errorf("opensky: request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := checkQuota(ctx, o.Name(), resp); err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("opensky: %s: %w", strings.SplitN(path, "?", 2)[0], ErrNotFound)
+	}
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("opensky: HTTP %d", resp.StatusCode)
+		return fmt.Errorf("opensky: HTTP %d", resp.StatusCode)
 	}
 
-	var raw openskyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		return nil, fmt.Errorf("opensky: decode error: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("opensky: decode error: %w", err)
 	}
+	return nil
+}
 
-	if len(raw.States) == 0 {
-		return nil, fmt.Errorf("opensky: ICAO24 %s not found", icao24)
+// GetRoute looks the flight's callsign up in OpenSky's route database, then
+// falls back to the most recent flight the aircraft flew under that callsign
+// in the last two days. OpenSky derives departure and arrival airports after
+// landing, so the fallback assumes the callsign flies the same route daily.
+func (o *OpenSkyProvider) GetRoute(ctx context.Context, flight *Flight) (*Route, error) {
+	callsign := callsignOf(flight)
+	if callsign == "" {
+		return nil, fmt.Errorf("opensky: no callsign: %w", ErrNotFound)
 	}
 
-	pos := stateToPosition(raw.States[0])
-	return &pos, nil
-}
+	var rt openskyRoute
+	err := o.getJSON(ctx, "/routes?callsign="+url.QueryEscape(callsign), &rt)
+	if err == nil && len(rt.Route) >= 2 {
+		// Multi-leg callsigns list every stop; the ends are the best guess
+		return &Route{
+			Origin:      AirportRefFor(rt.Route[0]),
+			Destination: AirportRefFor(rt.Route[len(rt.Route)-1]),
+		}, nil
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
 
-// isHexAddr returns true if the string looks like a 6-char ICAO24 hex address.
-func isHexAddr(s string) bool {
-	if len(s) != 6 {
-		return false
+	hex := flight.TransponderHex()
+	if hex == "" {
+		return nil, fmt.Errorf("opensky: route for %q: %w", callsign, ErrNotFound)
 	}
-	for _, c := range s {
-		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-			return false
+	end := time.Now()
+	begin := end.Add(-48*time.Hour + time.Minute) // the API caps the interval at two days
+	reportExtraRequest(ctx)
+	var flights []openskyFlight
+	if err := o.getJSON(ctx, fmt.Sprintf("/flights/aircraft?icao24=%s&begin=%d&end=%d", hex, begin.Unix(), end.Unix()), &flights); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("opensky: route for %q: %w", callsign, ErrNotFound)
+		}
+		return nil, err
+	}
+	var latest *openskyFlight
+	for i := range flights {
+		f := &flights[i]
+		if trimCallsign(f.Callsign) != callsign || f.EstDepartureAirport == "" || f.EstArrivalAirport == "" {
+			continue
+		}
+		if latest == nil || f.LastSeen > latest.LastSeen {
+			latest = f
 		}
 	}
-	return true
+	if latest == nil {
+		return nil, fmt.Errorf("opensky: route for %q: %w", callsign, ErrNotFound)
+	}
+	return &Route{
+		Origin:      AirportRefFor(latest.EstDepartureAirport),
+		Destination: AirportRefFor(latest.EstArrivalAirport),
+	}, nil
 }
 
 // ── OpenSky JSON types ──
@@ -269,6 +332,23 @@ type openskyResponse struct {
 	States []openskyStateVec `json:"states"`
 }
 
+// openskyRoute is a /routes entry: the airports a callsign serves, in order.
+type openskyRoute struct {
+	Callsign string   `json:"callsign"`
+	Route    []string `json:"route"`
+}
+
+// openskyFlight is a /flights/aircraft entry. Airports are ICAO codes, or
+// empty when OpenSky couldn't tell.
+type openskyFlight struct {
+	ICAO24              string `json:"icao24"`
+	Callsign            string `json:"callsign"`
+	FirstSeen           int64  `json:"firstSeen"`
+	LastSeen            int64  `json:"lastSeen"`
+	EstDepartureAirport string `json:"estDepartureAirport"`
+	EstArrivalAirport   string `json:"estArrivalAirport"`
+}
+
 // openskyStateVec is an OpenSky state vector (returned as a JSON array, not object).
 // Can be 17 or 18 elements depending on whether `extended` was requested.
 type openskyStateVec = []any
@@ -279,22 +359,12 @@ func stateToFlight(s openskyStateVec) Flight {
 	if len(s) > 0 {
 		if icao24, ok := s[0].(string); ok {
 			f.FlightID = icao24 // ICAO24 transponder hex
+			f.ICAO24 = icao24
 		}
 	}
 	if len(s) > 1 {
 		if callsign, ok := s[1].(string); ok {
-			f.Ident = trimCallsign(callsign)
-			f.IdentICAO = f.Ident
-
-			// Extract airline ICAO prefix and flight number from callsign
-			// e.g. "UAL2090" → prefix="UAL", flightNum="2090"
-			if prefix, flightNum := parseCallsign(f.Ident); prefix != "" {
-				f.OperatorICAO = prefix
-				if iata, ok := icaoToIATACode[prefix]; ok {
-					f.OperatorIATA = iata
-					f.IdentIATA = iata + flightNum
-				}
-			}
+			applyCallsign(&f, callsign)
 		}
 	}
 	if len(s) > 8 && s[8] != nil {
@@ -302,9 +372,30 @@ func stateToFlight(s openskyStateVec) Flight {
 			f.IsAirborne = false
 		}
 	}
+	if len(s) > 17 {
+		if cat, ok := toFloat(s[17]); ok {
+			f.Category = openskyCategories[int(cat)]
+		}
+	}
 	return f
 }
 
+// applyCallsign sets the flight's idents and operator codes from an ICAO callsign.
+func applyCallsign(f *Flight, callsign string) {
+	f.Ident = trimCallsign(callsign)
+	f.IdentICAO = f.Ident
+
+	// Extract airline ICAO prefix and flight number from callsign
+	// e.g. "UAL2090" → prefix="UAL", flightNum="2090"
+	if prefix, flightNum := parseCallsign(f.Ident); prefix != "" {
+		f.OperatorICAO = prefix
+		if a, ok := airlines.ByICAO(prefix); ok && a.IATA != "" {
+			f.OperatorIATA = a.IATA
+			f.IdentIATA = a.IATA + flightNum
+		}
+	}
+}
+
 // parseCallsign splits an ICAO callsign into airline prefix and flight number.
 // E.g. "UAL2090" → ("UAL", "2090"), "SWA456" → ("SWA", "456").
 func parseCallsign(cs string) (prefix, flightNum string) {
@@ -322,43 +413,28 @@ func parseCallsign(cs string) (prefix, flightNum string) {
 	return cs[:i], cs[i:]
 }
 
-// icaoToIATACode maps common ICAO airline designators to IATA codes.
-var icaoToIATACode = map[string]string{
-	// US Majors
-	"UAL": "UA", "AAL": "AA", "DAL": "DL", "SWA": "WN", "ASA": "AS",
-	"JBU": "B6", "NKS": "NK", "FFT": "F9", "HAL": "HA", "MXY": "MX",
-	// US Regionals
-	"SKW": "OO", "RPA": "YX", "ENY": "MQ", "PDT": "PT", "PSA": "OH",
-	"JIA": "OH", "CPZ": "QX", "GJS": "G7", "ACA": "AC",
-	// European
-	"AFR": "AF", "BAW": "BA", "DLH": "LH", "KLM": "KL", "SAS": "SK",
-	"FIN": "AY", "IBE": "IB", "AZA": "AZ", "TAP": "TP", "VIR": "VS",
-	"EIN": "EI", "EZY": "U2", "RYR": "FR", "SWR": "LX", "AUA": "OS",
-	"BEL": "SN", "LOT": "LO", "CSA": "OK", "EWG": "EW", "WZZ": "W6",
-	"VLG": "VY", "NOZ": "DY",
-	// Middle East
-	"UAE": "EK", "ETD": "EY", "QTR": "QR", "THY": "TK", "SAA": "SA",
-	"GFA": "GF", "MEA": "ME", "SVA": "SV", "ELY": "LY", "FDB": "FZ",
-	// Asian
-	"ANA": "NH", "JAL": "JL", "CPA": "CX", "SIA": "SQ", "EVA": "BR",
-	"CAL": "CI", "CCA": "CA", "CSN": "CZ", "CES": "MU", "HDA": "HU",
-	"KAL": "KE", "AAR": "OZ", "THA": "TH", "MAS": "MH", "VNA": "VN",
-	"GIA": "GA", "AXM": "AK", "PAL": "PR", "CEB": "5J",
-	"AIC": "AI", "IGO": "6E", "SEJ": "RS",
-	// Oceania
-	"QFA": "QF", "ANZ": "NZ", "JST": "JQ",
-	// Americas
-	"WJA": "WS", "AMX": "AM", "GLO": "G3", "AVA": "AV", "CMP": "CM",
-	"LAN": "LA", "VOI": "Y4", "TAM": "JJ", "AZU": "AD",
-	// Africa
-	"ETH": "ET", "SAW": "SA", "MSR": "MS",
-}
-
 func stateToPosition(s openskyStateVec) FlightPosition {
-	pos := FlightPosition{
-		Timestamp: time.Now(),
+	var pos FlightPosition
+
+	// time_position dates the position; last_contact is the last message of
+	// any kind. Both are unix seconds, and time_position is null if OpenSky
+	// has had no position for 15 seconds.
+	if len(s) > 4 {
+		if t, ok := toFloat(s[4]); ok {
+			pos.LastContact = time.Unix(int64(t), 0)
+		}
+	}
+	if len(s) > 3 {
+		if t, ok := toFloat(s[3]); ok {
+			pos.Timestamp = time.Unix(int64(t), 0)
+		}
+	}
+	if pos.Timestamp.IsZero() {
+		pos.Timestamp = pos.LastContact
+	}
+	if pos.Timestamp.IsZero() {
+		pos.Timestamp = time.Now()
 	}
-
 	if len(s) > 6 {
 		if lat, ok := toFloat(s[6]); ok {
 			pos.Latitude = lat
@@ -370,13 +446,13 @@ func stateToPosition(s openskyStateVec) FlightPosition {
 		}
 	}
 	if len(s) > 7 {
-		if alt, ok := toFloat(s[7]); ok { // baro_altitude in meters
-			pos.Altitude = int(alt * 3.28084 / 100) // convert to flight levels (100ft)
+		if alt, ok := toFloat(s[7]); ok { // baro_altitude in metres
+			pos.BaroAltitude = metresToFeet(alt)
 		}
 	}
 	if len(s) > 9 {
 		if spd, ok := toFloat(s[9]); ok { // velocity in m/s
-			pos.Groundspeed = int(spd * 1.94384) // convert to knots
+			pos.Groundspeed = metresPerSecToKnots(spd)
 		}
 	}
 	if len(s) > 10 {
@@ -387,19 +463,45 @@ func stateToPosition(s openskyStateVec) FlightPosition {
 	}
 	if len(s) > 11 {
 		if vrate, ok := toFloat(s[11]); ok { // vertical_rate in m/s
-			if vrate > 1 {
-				pos.AltitudeChange = "C"
-			} else if vrate < -1 {
-				pos.AltitudeChange = "D"
-			} else {
-				pos.AltitudeChange = "-"
-			}
+			vr := metresPerSecToFPM(vrate)
+			pos.VerticalRate = &vr
+		}
+	}
+	if len(s) > 13 {
+		if alt, ok := toFloat(s[13]); ok { // geo_altitude in metres
+			pos.GeoAltitude = metresToFeet(alt)
+		}
+	}
+	if len(s) > 14 {
+		if sq, ok := s[14].(string); ok {
+			pos.Squawk = sq
+		}
+	}
+	if len(s) > 16 {
+		if src, ok := toFloat(s[16]); ok {
+			pos.Source = openskySources[int(src)]
 		}
 	}
 
 	return pos
 }
 
+// openskySources maps OpenSky's position_source (state vector index 16).
+var openskySources = map[int]PositionSource{
+	0: SourceADSB,
+	1: SourceRadar, // ASTERIX
+	2: SourceMLAT,
+	3: SourceFLARM,
+}
+
+// openskyCategories maps OpenSky's category (state vector index 17, only
+// with extended=1) to the ADS-B emitter category. 0 and 1 mean unknown.
+var openskyCategories = map[int]EmitterCategory{
+	2: "A1", 3: "A2", 4: "A3", 5: "A4", 6: "A5", 7: "A6", 8: "A7",
+	9: "B1", 10: "B2", 11: "B3", 12: "B4", 13: "B5", 14: "B6", 15: "B7",
+	16: "C1", 17: "C2", 18: "C3", 19: "C4", 20: "C5",
+}
+
 func toFloat(v any) (float64, bool) {
 	if v == nil {
 		return 0, false
@@ -418,20 +520,20 @@ func trimCallsign(cs string) string {
 	return strings.TrimSpace(cs)
 }
 
-// airportCoords returns the lat/lon for known airports.
+// unknownAirports records codes airportCoords has already warned about.
+var unknownAirports sync.Map
+
+// airportCoords returns the lat/lon for a station or any airport in the
+// database. Unknown codes fall back to the default station, with a warning
+// logged the first time each is seen.
 func airportCoords(icao string) (float64, float64) {
-	coords := map[string][2]float64{
-		"KSFO": {37.6213, -122.3790},
-		"KLAX": {33.9425, -118.4081},
-		"KJFK": {40.6413, -73.7781},
-		"KORD": {41.9742, -87.9073},
-		"KATL": {33.6407, -84.4277},
-		"EGLL": {51.4700, -0.4543},
-		"RJTT": {35.5494, 139.7798},
-	}
-	if c, ok := coords[icao]; ok {
-		return c[0], c[1]
-	}
-	// Default to SFO
-	return sfoLatOS, sfoLonOS
+	st, ok := station.Lookup(icao)
+	if ok {
+		return st.Lat, st.Lon
+	}
+	st = station.Default()
+	if _, warned := unknownAirports.LoadOrStore(icao, true); !warned {
+		log.Printf("[provider] unknown airport %q, searching around %s", icao, st.Code)
+	}
+	return st.Lat, st.Lon
 }
@@ -0,0 +1,21 @@
+package provider
+
+import "testing"
+
+func TestOpenSkyCredits(t *testing.T) {
+	tests := []struct {
+		name string
+		box  BoundingBox
+		want int
+	}{
+		{"near box", boxAround(37.62, -122.38, 1.0), 1},
+		{"position search box", boxAround(37.62, -122.38, 5.0), 2},
+		{"15 degrees square", BoundingBox{LatMin: 30, LonMin: -130, LatMax: 45, LonMax: -115}, 3},
+		{"continent", BoundingBox{LatMin: 20, LonMin: -130, LatMax: 50, LonMax: -60}, 4},
+	}
+	for _, tt := range tests {
+		if got := openskyCredits(tt.box); got != tt.want {
+			t.Errorf("%s: openskyCredits = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
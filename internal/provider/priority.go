@@ -0,0 +1,44 @@
+package provider
+
+import "context"
+
+// Priority classifies a request for rate budgeting. Each RateLimit holds part
+// of its window back for higher classes so low-value polling can't starve them.
+type Priority int
+
+const (
+	// PriorityDiscovery is best-effort polling for nearby flights. It may use
+	// everything except the featured reserve.
+	PriorityDiscovery Priority = iota
+	// PriorityFeatured is position polling for the featured flight. It has a
+	// reserved share of every window and may also use any spare capacity.
+	PriorityFeatured
+	// PriorityBackground is enrichment (routes, aircraft details). It only
+	// uses capacity while the window is mostly unused.
+	PriorityBackground
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityFeatured:
+		return "featured"
+	case PriorityBackground:
+		return "background"
+	}
+	return "discovery"
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose provider requests are budgeted as p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the priority set on ctx, or def if none was set.
+func priorityFrom(ctx context.Context, def Priority) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return def
+}
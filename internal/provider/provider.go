@@ -1,14 +1,39 @@
 package provider
 
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is wrapped by GetFlightPosition errors when the provider answered
+// but does not know the aircraft. It is not counted against provider health.
+var ErrNotFound = errors.New("aircraft not found")
+
 // FlightProvider is the interface for all flight data sources.
 type FlightProvider interface {
 	// Name returns a human-readable provider name for logging.
 	Name() string
 
 	// GetFlightsNear returns en-route flights near the given airport.
-	GetFlightsNear(airportICAO string, direction FlightDirection) ([]Flight, error)
+	// Implementations must abandon in-flight requests when ctx is cancelled.
+	GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error)
 
 	// GetFlightPosition returns the latest position for a flight.
 	// Accepts the full Flight so each provider can use its preferred lookup field.
-	GetFlightPosition(flight *Flight) (*FlightPosition, error)
+	GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error)
+}
+
+// unwrapAs returns p, or the outermost provider it wraps, that is a T.
+func unwrapAs[T any](p FlightProvider) (T, bool) {
+	for {
+		if t, ok := p.(T); ok {
+			return t, true
+		}
+		w, ok := p.(Wrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		p = w.Unwrap()
+	}
 }
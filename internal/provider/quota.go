@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// QuotaSignal is a provider server's own view of its quota, taken from
+// response headers or a 429 response.
+type QuotaSignal struct {
+	Remaining  int           // requests (OpenSky: credits) left in the server's window, -1 if not reported
+	RetryAfter time.Duration // back off this long before the next request, 0 if not reported
+}
+
+// RateLimitedError is returned when a request is refused for quota reasons:
+// by the provider's server (HTTP 429) or by a provider's own spend budget.
+type RateLimitedError struct {
+	Provider   string
+	Reason     string        // e.g. "server quota", "monthly budget"
+	RetryAfter time.Duration // 0 if unknown
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: rate limited (%s), retry after %v", e.Provider, e.Reason, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("%s: rate limited (%s)", e.Provider, e.Reason)
+}
+
+// spendLimiter is implemented by providers with a spending budget of their
+// own besides their rate limit, such as AeroAPI's monthly dollar cap.
+// MultiProvider checks it before using a rate slot, so a provider over budget
+// doesn't spend the slot only to refuse the call.
+type spendLimiter interface {
+	// checkSpend returns a *RateLimitedError if a request of class p would
+	// go over budget. Otherwise the caller must make the request.
+	checkSpend(ctx context.Context, p Priority) error
+}
+
+type extraRequestKey struct{}
+
+// withExtraRequestReporter returns a context whose provider calls invoke fn
+// for each upstream request they make beyond the first.
+func withExtraRequestReporter(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, extraRequestKey{}, fn)
+}
+
+// reportExtraRequest tells the caller registered in ctx that a call is about
+// to make another upstream request, so it can be counted.
+func reportExtraRequest(ctx context.Context) {
+	if fn, ok := ctx.Value(extraRequestKey{}).(func()); ok {
+		fn()
+	}
+}
+
+type quotaReporterKey struct{}
+
+// withQuotaReporter returns a context whose provider calls deliver quota
+// signals to fn.
+func withQuotaReporter(ctx context.Context, fn func(QuotaSignal)) context.Context {
+	return context.WithValue(ctx, quotaReporterKey{}, fn)
+}
+
+// checkQuota reports any quota headers on resp to the caller registered in
+// ctx and turns a 429 into a *RateLimitedError. Providers call it right after
+// receiving a response, before checking the status code.
+//
+// Understood headers: X-Rate-Limit-Remaining and
+// X-Rate-Limit-Retry-After-Seconds (OpenSky), and standard Retry-After.
+func checkQuota(ctx context.Context, provider string, resp *http.Response) error {
+	q := QuotaSignal{Remaining: -1}
+	if v := resp.Header.Get("X-Rate-Limit-Remaining"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			q.Remaining = n
+		}
+	}
+	if v := resp.Header.Get("X-Rate-Limit-Retry-After-Seconds"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			q.RetryAfter = time.Duration(n) * time.Second
+		}
+	}
+	if q.RetryAfter == 0 {
+		q.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+
+	limited := resp.StatusCode == http.StatusTooManyRequests
+	if limited && q.Remaining < 0 && q.RetryAfter == 0 {
+		q.Remaining = 0 // server gave no detail; treat the window as spent
+	}
+
+	if q.Remaining >= 0 || q.RetryAfter > 0 {
+		if fn, ok := ctx.Value(quotaReporterKey{}).(func(QuotaSignal)); ok {
+			fn(q)
+		}
+	}
+	if limited {
+		return &RateLimitedError{Provider: provider, Reason: "server quota", RetryAfter: q.RetryAfter}
+	}
+	return nil
+}
+
+// parseRetryAfter parses a Retry-After header in delay-seconds or HTTP-date form.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(v); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -1,53 +1,345 @@
 package provider
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
-// RateLimit tracks API consumption using a sliding window.
+// Default division of each window among priority classes.
+const (
+	defaultFeaturedReserve   = 0.25 // share held back for PriorityFeatured
+	defaultBackgroundCeiling = 0.50 // PriorityBackground stops once this share is used
+)
+
+// RateLimit tracks API consumption using a sliding window, or a calendar
+// month for providers billed monthly. The window is divided among priority
+// classes: a share is reserved for featured-flight polls, discovery may use
+// the rest, and background work only runs while the window is mostly unused.
 type RateLimit struct {
 	mu       sync.Mutex
 	window   time.Duration
+	monthly  bool // window is the current calendar month (UTC), not a sliding duration
 	maxReqs  int
 	requests []time.Time // timestamps of recent requests
+	featured []time.Time // timestamps of recent PriorityFeatured requests (subset of requests)
+	blocked  time.Time   // no requests before this time (server Retry-After)
+	path     string      // file the window is persisted to, empty = memory only
+	dirty    bool        // window changed since it was last written
+	flushing bool        // a write is scheduled
+	writeMu  sync.Mutex  // serializes writes to path; taken before mu
+
+	featuredReserve   float64
+	backgroundCeiling float64
 }
 
 // NewRateLimit creates a rate limiter with the given window and max requests.
 // Example: NewRateLimit(10, time.Minute) allows 10 requests per minute.
 func NewRateLimit(maxReqs int, window time.Duration) *RateLimit {
 	return &RateLimit{
-		window:  window,
-		maxReqs: maxReqs,
+		window:            window,
+		maxReqs:           maxReqs,
+		featuredReserve:   defaultFeaturedReserve,
+		backgroundCeiling: defaultBackgroundCeiling,
+	}
+}
+
+// NewMonthlyRateLimit creates a rate limiter allowing maxReqs requests per
+// calendar month (UTC), resetting on the 1st like a monthly billing quota.
+func NewMonthlyRateLimit(maxReqs int) *RateLimit {
+	r := NewRateLimit(maxReqs, 0)
+	r.monthly = true
+	return r
+}
+
+// SetShares changes how the window is divided: featuredReserve is the share
+// held back for PriorityFeatured, and PriorityBackground is refused once
+// backgroundCeiling of the window is used, or earlier if it would eat into
+// what is left of the featured reserve. Both are fractions of maxReqs.
+func (r *RateLimit) SetShares(featuredReserve, backgroundCeiling float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.featuredReserve = featuredReserve
+	r.backgroundCeiling = backgroundCeiling
+}
+
+// ceiling returns how many requests may be in the window before requests of
+// class p are refused. The featured reserve shrinks as featured polls use it.
+// Must be called with mu held.
+func (r *RateLimit) ceiling(p Priority) int {
+	reserved := int(math.Ceil(float64(r.maxReqs)*r.featuredReserve)) - len(r.featured)
+	if reserved < 0 {
+		reserved = 0
+	}
+	switch p {
+	case PriorityFeatured:
+		return r.maxReqs
+	case PriorityBackground:
+		return min(int(float64(r.maxReqs)*r.backgroundCeiling), r.maxReqs-reserved)
+	}
+	return r.maxReqs - reserved
+}
+
+// String describes the limit, e.g. "10 requests per 1m0s".
+func (r *RateLimit) String() string {
+	if r.monthly {
+		return fmt.Sprintf("%d requests per calendar month", r.maxReqs)
+	}
+	return fmt.Sprintf("%d requests per %v", r.maxReqs, r.window)
+}
+
+// windowStart returns the earliest request time still counted against the limit.
+func (r *RateLimit) windowStart(now time.Time) time.Time {
+	if r.monthly {
+		return monthStart(now)
+	}
+	return now.Add(-r.window)
+}
+
+// windowEnd returns when a request made at t stops counting against the limit.
+func (r *RateLimit) windowEnd(t time.Time) time.Time {
+	if r.monthly {
+		return monthStart(t).AddDate(0, 1, 0)
+	}
+	return t.Add(r.window)
+}
+
+// Persist loads previously saved request timestamps from path and saves the
+// window there shortly after each change, so budgets survive restarts. Call
+// Flush before exiting to write the last changes. A missing file is not an
+// error.
+func (r *RateLimit) Persist(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+	var saved rateLimitFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("ratelimit: %s: %w", path, err)
+	}
+
+	if saved.BlockedUntil.After(r.blocked) {
+		r.blocked = saved.BlockedUntil
+	}
+
+	// Merge with anything recorded before Persist was called, keeping order.
+	r.requests = mergeTimes(saved.Requests, r.requests)
+	r.featured = mergeTimes(saved.Featured, r.featured)
+	r.prune()
+	return nil
+}
+
+// saveDelay is how long a changed window waits before it is written, so a
+// burst of requests costs one write rather than one each.
+const saveDelay = 5 * time.Second
+
+// save marks the window changed and schedules a write to the persist path.
+// Must be called with mu held.
+func (r *RateLimit) save() {
+	if r.path == "" {
+		return
+	}
+	r.dirty = true
+	if !r.flushing {
+		r.flushing = true
+		time.AfterFunc(saveDelay, func() {
+			if err := r.Flush(); err != nil {
+				log.Printf("[ratelimit] save error: %v", err)
+			}
+		})
+	}
+}
+
+// Flush writes the window to the persist path now if it has changed since
+// it was last written. Call it before exiting so no requests are lost.
+func (r *RateLimit) Flush() error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	r.mu.Lock()
+	r.flushing = false
+	if !r.dirty {
+		r.mu.Unlock()
+		return nil
+	}
+	r.dirty = false
+	path := r.path
+	data, err := json.Marshal(rateLimitFile{Requests: r.requests, Featured: r.featured, BlockedUntil: r.blocked})
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("ratelimit: %w", err)
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		// Try again with the next change or flush.
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return fmt.Errorf("ratelimit: %w", err)
 	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames
+// it into place, so a crash mid-write can't leave a truncated file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// rateLimitFile is the on-disk form of a persisted window.
+type rateLimitFile struct {
+	Requests     []time.Time `json:"requests"`
+	Featured     []time.Time `json:"featured,omitempty"`
+	BlockedUntil time.Time   `json:"blocked_until"`
+}
+
+// mergeTimes returns a and b combined in chronological order.
+func mergeTimes(a, b []time.Time) []time.Time {
+	out := append(a, b...)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Before(out[j])
+	})
+	return out
 }
 
-// prune removes expired timestamps (older than window).
+// prune removes expired timestamps (outside the window).
 // Must be called with mu held.
 func (r *RateLimit) prune() {
-	cutoff := time.Now().Add(-r.window)
+	cutoff := r.windowStart(time.Now())
+	r.requests = pruneBefore(r.requests, cutoff)
+	r.featured = pruneBefore(r.featured, cutoff)
+}
+
+// pruneBefore drops the leading timestamps older than cutoff.
+func pruneBefore(times []time.Time, cutoff time.Time) []time.Time {
 	i := 0
-	for i < len(r.requests) && r.requests[i].Before(cutoff) {
+	for i < len(times) && times[i].Before(cutoff) {
 		i++
 	}
-	if i > 0 {
-		r.requests = r.requests[i:]
-	}
+	return times[i:]
+}
+
+// isBlocked reports whether a server-imposed backoff is in effect.
+// Must be called with mu held.
+func (r *RateLimit) isBlocked() bool {
+	return time.Now().Before(r.blocked)
 }
 
-// Allow returns true if a request can be made without exceeding the limit.
+// Allow returns true if a discovery request fits in the window. It pairs
+// with Record.
 func (r *RateLimit) Allow() bool {
+	return r.AllowPriority(PriorityDiscovery)
+}
+
+// AllowPriority returns true if a request of class p fits in its share of the window.
+func (r *RateLimit) AllowPriority(p Priority) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	return !r.isBlocked() && len(r.requests) < r.ceiling(p)
+}
+
+// Sync re-aligns the window with the server's count of remaining requests,
+// so requests made by other clients or before a restart are accounted for.
+func (r *RateLimit) Sync(remaining int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.prune()
-	return len(r.requests) < r.maxReqs
+	if remaining > r.maxReqs {
+		remaining = r.maxReqs
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	used := r.maxReqs - remaining
+	switch {
+	case used > len(r.requests):
+		// The server has seen more than we have; count the difference as made now.
+		now := time.Now()
+		for len(r.requests) < used {
+			r.requests = append(r.requests, now)
+		}
+	case used < len(r.requests):
+		// The server is more generous; forget our oldest requests.
+		r.requests = r.requests[len(r.requests)-used:]
+		if len(r.requests) == 0 {
+			r.featured = nil
+		} else {
+			r.featured = pruneBefore(r.featured, r.requests[0])
+		}
+	default:
+		return
+	}
+	r.save()
+}
+
+// BlockFor stops all requests for d, as instructed by a server's Retry-After.
+func (r *RateLimit) BlockFor(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if until := time.Now().Add(d); until.After(r.blocked) {
+		r.blocked = until
+		r.save()
+	}
 }
 
-// Record records that a request was just made.
+// Record records that a discovery request was just made.
 func (r *RateLimit) Record() {
+	r.RecordPriority(PriorityDiscovery)
+}
+
+// RecordPriority records that a request of class p was just made.
+func (r *RateLimit) RecordPriority(p Priority) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.requests = append(r.requests, now)
+	if p == PriorityFeatured {
+		r.featured = append(r.featured, now)
+	}
+	r.save()
+}
+
+// Refund removes the most recent request of class p, for a call that was
+// recorded but answered without reaching the provider (e.g. from a cache).
+func (r *RateLimit) Refund(p Priority) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.requests = append(r.requests, time.Now())
+	if n := len(r.requests); n > 0 {
+		r.requests = r.requests[:n-1]
+	}
+	if n := len(r.featured); p == PriorityFeatured && n > 0 {
+		r.featured = r.featured[:n-1]
+	}
+	r.save()
 }
 
 // Remaining returns how many requests can still be made in the current window.
@@ -56,7 +348,20 @@ func (r *RateLimit) Remaining() int {
 	defer r.mu.Unlock()
 	r.prune()
 	rem := r.maxReqs - len(r.requests)
-	if rem < 0 {
+	if rem < 0 || r.isBlocked() {
+		return 0
+	}
+	return rem
+}
+
+// RemainingFor returns how many requests of class p can still be made in the
+// current window, within that class's ceiling.
+func (r *RateLimit) RemainingFor(p Priority) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	rem := r.ceiling(p) - len(r.requests)
+	if rem < 0 || r.isBlocked() {
 		return 0
 	}
 	return rem
@@ -76,26 +381,50 @@ func (r *RateLimit) CapacityPct() float64 {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.prune()
-	if r.maxReqs == 0 {
+	if r.maxReqs == 0 || r.isBlocked() {
 		return 0
 	}
 	return float64(r.maxReqs-len(r.requests)) / float64(r.maxReqs)
 }
 
+// Horizon returns how long the requests remaining now must last: the window
+// length for a sliding window, or the time until the month resets.
+func (r *RateLimit) Horizon() time.Duration {
+	if r.monthly {
+		return time.Until(r.windowEnd(time.Now()))
+	}
+	return r.window
+}
+
+// CapacityFor returns the remaining share of the window available to class p
+// (0.0 to 1.0), relative to that class's ceiling.
+func (r *RateLimit) CapacityFor(p Priority) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	ceil := r.ceiling(p)
+	if ceil <= 0 || r.isBlocked() {
+		return 0
+	}
+	left := ceil - len(r.requests)
+	if left <= 0 {
+		return 0
+	}
+	return float64(left) / float64(ceil)
+}
+
 // WaitDuration returns how long to wait before another request can be made.
 // Returns 0 if a request can be made immediately.
 func (r *RateLimit) WaitDuration() time.Duration {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.prune()
+	block := time.Until(r.blocked)
 	if len(r.requests) < r.maxReqs {
-		return 0
+		return max(block, 0)
 	}
 	// The oldest request in the window determines when next slot opens.
 	oldest := r.requests[0]
-	wait := time.Until(oldest.Add(r.window))
-	if wait < 0 {
-		return 0
-	}
-	return wait
+	wait := time.Until(r.windowEnd(oldest))
+	return max(wait, block, 0)
 }
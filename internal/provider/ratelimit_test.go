@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPersistFlush(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ratelimits")
+	path := filepath.Join(dir, "opensky.json")
+
+	r := NewRateLimit(10, time.Hour)
+	if err := r.Persist(path); err != nil {
+		t.Fatal(err)
+	}
+	r.Record()
+	r.RecordPriority(PriorityFeatured)
+
+	// Writes are deferred, so a burst of requests isn't a write each.
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("window written before a flush: err = %v", err)
+	}
+	if err := r.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewRateLimit(10, time.Hour)
+	if err := restored.Persist(path); err != nil {
+		t.Fatal(err)
+	}
+	if used := restored.Used(); used != 2 {
+		t.Errorf("restored %d requests, want 2", used)
+	}
+
+	// Nothing but the state file is left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "opensky.json" {
+		t.Errorf("persist dir holds %v, want only opensky.json", entries)
+	}
+}
@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// readsbMaxPosAge drops aircraft whose last position is older than this.
+const readsbMaxPosAge = 60 * time.Second
+
+// ReadsbProvider implements FlightProvider by polling an aircraft.json file as
+// written by readsb, dump1090-fa and tar1090. The source can be an HTTP URL
+// (e.g. "http://localhost/tar1090/data/aircraft.json") or a local file path
+// (e.g. "/run/readsb/aircraft.json").
+type ReadsbProvider struct {
+	source     string
+	httpClient *http.Client
+}
+
+// NewReadsbProvider creates a provider reading aircraft.json from source.
+func NewReadsbProvider(source string) *ReadsbProvider {
+	return &ReadsbProvider{
+		source: source,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+func (r *ReadsbProvider) Name() string { return "readsb" }
+
+// fetch reads and decodes the current aircraft.json.
+func (r *ReadsbProvider) fetch(ctx context.Context) (*readsbResponse, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(r.source, "http://") || strings.HasPrefix(r.source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("readsb: %w", err)
+		}
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("readsb: request failed: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("readsb: HTTP %d", resp.StatusCode)
+		}
+		body = resp.Body
+	} else {
+		f, err := os.Open(r.source)
+		if err != nil {
+			return nil, fmt.Errorf("readsb: %w", err)
+		}
+		body = f
+	}
+	defer body.Close()
+
+	var raw readsbResponse
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("readsb: decode error: %w", err)
+	}
+
+	// A decoder that died leaves the last file behind — don't serve it as live.
+	if raw.Now > 0 {
+		age := time.Since(time.UnixMilli(int64(raw.Now * 1000)))
+		if age > readsbMaxPosAge {
+			return nil, fmt.Errorf("readsb: aircraft.json is stale (%v old)", age.Round(time.Second))
+		}
+	}
+	return &raw, nil
+}
+
+// GetFlightsNear returns airborne aircraft with a recent position around the airport.
+// aircraft.json carries no route data, so direction is ignored.
+func (r *ReadsbProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	raw, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, lon := airportCoords(airportICAO)
+	delta := 1.0 // same ~60nm box as OpenSky
+
+	var flights []Flight
+	for _, ac := range raw.Aircraft {
+		if !ac.hasPosition() || ac.Flight == "" {
+			continue
+		}
+		if ac.Lat < lat-delta || ac.Lat > lat+delta || ac.Lon < lon-delta || ac.Lon > lon+delta {
+			continue
+		}
+		f := ac.toFlight()
+		if f.IsAirborne {
+			flights = append(flights, f)
+		}
+	}
+	return flights, nil
+}
+
+// GetPositionsNear returns every aircraft with a recent position around the airport.
+func (r *ReadsbProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	raw, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lat, lon := airportCoords(airportICAO)
+	snap := &AreaSnapshot{Box: boxAround(lat, lon, 1.0), FetchedAt: time.Now()}
+	for _, ac := range raw.Aircraft {
+		if !ac.hasPosition() || !snap.Box.Contains(ac.Lat, ac.Lon) {
+			continue
+		}
+		snap.Flights = append(snap.Flights, ac.toFlight())
+		snap.Positions = append(snap.Positions, ac.toPosition(raw.Now))
+	}
+	return snap, nil
+}
+
+// GetFlightPosition returns the latest position for a flight, matched by
+// ICAO24 hex first and then by callsign.
+func (r *ReadsbProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	raw, err := r.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hex := flight.TransponderHex()
+	var match *readsbAircraft
+	for i := range raw.Aircraft {
+		ac := &raw.Aircraft[i]
+		if hex != "" && strings.EqualFold(ac.Hex, hex) {
+			match = ac
+			break
+		}
+		cs := trimCallsign(ac.Flight)
+		if match == nil && cs != "" && (strings.EqualFold(cs, flight.Ident) || strings.EqualFold(cs, flight.IdentICAO)) {
+			match = ac
+		}
+	}
+	if match == nil || !match.hasPosition() {
+		return nil, fmt.Errorf("readsb: %q: %w", flight.Ident, ErrNotFound)
+	}
+
+	pos := match.toPosition(raw.Now)
+	return &pos, nil
+}
+
+// ── readsb JSON types ──
+
+type readsbResponse struct {
+	Now      float64          `json:"now"` // unix seconds
+	Aircraft []readsbAircraft `json:"aircraft"`
+}
+
+type readsbAircraft struct {
+	Hex          string   `json:"hex"`      // ICAO24, "~" prefix for non-ICAO addresses
+	Type         string   `json:"type"`     // source of the data, e.g. "adsb_icao", "mlat"
+	Flight       string   `json:"flight"`   // callsign, space padded
+	Registration string   `json:"r"`        // from readsb's aircraft database, if loaded
+	TypeCode     string   `json:"t"`        // likewise
+	Category     string   `json:"category"` // emitter category, e.g. "A3"
+	AltBaro      any      `json:"alt_baro"` // feet, or the string "ground"
+	AltGeom      *float64 `json:"alt_geom"` // feet
+	GS           *float64 `json:"gs"`       // knots
+	Track        *float64 `json:"track"`    // degrees true
+	BaroRate     *float64 `json:"baro_rate"`
+	GeomRate     *float64 `json:"geom_rate"`
+	Squawk       string   `json:"squawk"`
+	Lat          float64  `json:"lat"`
+	Lon          float64  `json:"lon"`
+	SeenPos      *float64 `json:"seen_pos"` // seconds since last position
+	Seen         *float64 `json:"seen"`     // seconds since last message
+}
+
+// hasPosition returns true for ICAO-addressed aircraft with a recent position.
+func (a *readsbAircraft) hasPosition() bool {
+	if !isHexAddr(a.Hex) || a.SeenPos == nil {
+		return false
+	}
+	return time.Duration(*a.SeenPos*float64(time.Second)) <= readsbMaxPosAge
+}
+
+func (a *readsbAircraft) onGround() bool {
+	s, ok := a.AltBaro.(string)
+	return ok && s == "ground"
+}
+
+func (a *readsbAircraft) toFlight() Flight {
+	f := Flight{
+		FlightID:     strings.ToLower(a.Hex), // ICAO24 transponder hex
+		ICAO24:       strings.ToLower(a.Hex),
+		Registration: a.Registration,
+		AircraftType: a.TypeCode,
+		Category:     EmitterCategory(a.Category),
+		IsAirborne:   !a.onGround(),
+	}
+	applyCallsign(&f, a.Flight)
+	return f
+}
+
+// readsbSource maps readsb's data type to a position source.
+func readsbSource(typ string) PositionSource {
+	switch {
+	case strings.HasPrefix(typ, "adsb_"):
+		return SourceADSB
+	case typ == "mlat":
+		return SourceMLAT
+	case strings.HasPrefix(typ, "tisb_"), strings.HasPrefix(typ, "adsr_"):
+		return SourceTISB
+	case typ == "adsc":
+		return SourceSatellite
+	}
+	return ""
+}
+
+// toPosition converts the aircraft to a FlightPosition. now is the file's
+// "now" timestamp, used with seen_pos to date the position.
+func (a *readsbAircraft) toPosition(now float64) FlightPosition {
+	pos := FlightPosition{
+		Latitude:  a.Lat,
+		Longitude: a.Lon,
+		Squawk:    a.Squawk,
+		Source:    readsbSource(a.Type),
+		Timestamp: time.Now(),
+	}
+	if now > 0 && a.SeenPos != nil {
+		pos.Timestamp = time.UnixMilli(int64((now - *a.SeenPos) * 1000))
+	}
+	if now > 0 && a.Seen != nil {
+		pos.LastContact = time.UnixMilli(int64((now - *a.Seen) * 1000))
+	}
+	if alt, ok := toFloat(a.AltBaro); ok {
+		pos.BaroAltitude = Feet(alt)
+	}
+	if a.AltGeom != nil {
+		pos.GeoAltitude = Feet(*a.AltGeom)
+	}
+	if a.GS != nil {
+		pos.Groundspeed = Knots(*a.GS)
+	}
+	if a.Track != nil {
+		h := int(*a.Track)
+		pos.Heading = &h
+	}
+	rate := a.BaroRate
+	if rate == nil {
+		rate = a.GeomRate
+	}
+	if rate != nil {
+		vr := FeetPerMinute(*rate)
+		pos.VerticalRate = &vr
+	}
+	if a.onGround() {
+		vr := FeetPerMinute(0)
+		pos.VerticalRate = &vr
+	}
+	return pos
+}
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// readsbAircraftJSON is an aircraft.json as readsb writes it, around SFO.
+// "now" is filled in when served so the file isn't stale.
+const readsbAircraftJSON = `{"now": %.3f, "messages": 123456, "aircraft": [
+  {"hex": "a1b2c3", "type": "adsb_icao", "flight": "UAL123  ", "t": "B738", "category": "A3",
+   "alt_baro": 12000, "alt_geom": 12350, "gs": 251.4, "track": 90.2, "baro_rate": -640, "geom_rate": -704,
+   "squawk": "1234", "lat": 37.7, "lon": -122.3, "seen_pos": 2.0, "seen": 0.5},
+  {"hex": "abcdef", "type": "adsb_icao", "flight": "DAL9    ",
+   "alt_baro": "ground", "gs": 12, "track": 280,
+   "lat": 37.62, "lon": -122.38, "seen_pos": 1.0, "seen": 1.0},
+  {"hex": "c0ffee", "type": "mlat", "flight": "SWA1    ",
+   "alt_baro": 5000, "geom_rate": 1216, "gs": 210,
+   "lat": 37.5, "lon": -122.2, "seen_pos": 0.0, "seen": 0.0},
+  {"hex": "123456", "type": "adsb_icao", "flight": "AAL1    ", "alt_baro": 9000,
+   "lat": 37.65, "lon": -122.35, "seen_pos": 120.0, "seen": 3.0},
+  {"hex": "~1a2b3c", "type": "tisb_trackfile", "alt_baro": 3000,
+   "lat": 37.6, "lon": -122.3, "seen_pos": 1.0, "seen": 1.0},
+  {"hex": "654321", "type": "mode_s", "flight": "JBU5    ", "alt_baro": 35000, "seen": 4.0}
+]}`
+
+func TestReadsbAircraftJSON(t *testing.T) {
+	now := time.Now()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, readsbAircraftJSON, float64(now.UnixMilli())/1000)
+	}))
+	defer srv.Close()
+
+	r := NewReadsbProvider(srv.URL + "/data/aircraft.json")
+	snap, err := r.GetPositionsNear(context.Background(), "KSFO")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Aircraft whose position is too old, non-ICAO addresses and aircraft
+	// with no position at all are dropped.
+	positions := make(map[string]FlightPosition)
+	for i, f := range snap.Flights {
+		positions[f.ICAO24] = snap.Positions[i]
+	}
+	if len(positions) != 3 {
+		t.Fatalf("snapshot has %v, want a1b2c3, abcdef and c0ffee", snap.Flights)
+	}
+
+	ual, ok := positions["a1b2c3"]
+	if !ok {
+		t.Fatal("UAL123 missing from the snapshot")
+	}
+	if ual.BaroAltitude != 12000 || ual.GeoAltitude != 12350 || ual.Groundspeed != 251 || ual.Source != SourceADSB {
+		t.Errorf("UAL123 = %d ft baro, %d ft geo, %d kt, %q; want 12000, 12350, 251, adsb",
+			ual.BaroAltitude, ual.GeoAltitude, ual.Groundspeed, ual.Source)
+	}
+	if ual.Heading == nil || *ual.Heading != 90 {
+		t.Errorf("UAL123 heading = %v, want 90", ual.Heading)
+	}
+	// The barometric rate is preferred over the geometric one.
+	if ual.VerticalRate == nil || *ual.VerticalRate != -640 {
+		t.Errorf("UAL123 vertical rate = %v, want -640", ual.VerticalRate)
+	}
+	// seen_pos dates the position back from "now".
+	if want := now.Add(-2 * time.Second); ual.Timestamp.Sub(want).Abs() > 2*time.Millisecond {
+		t.Errorf("UAL123 position time = %v, want %v", ual.Timestamp, want)
+	}
+
+	// Without a barometric rate the geometric one is used.
+	if swa := positions["c0ffee"]; swa.VerticalRate == nil || *swa.VerticalRate != 1216 || swa.Source != SourceMLAT {
+		t.Errorf("SWA1 vertical rate = %v, source %q; want 1216, mlat", swa.VerticalRate, swa.Source)
+	}
+
+	// "ground" is not an altitude: the aircraft is on the ground and level.
+	dal := positions["abcdef"]
+	if dal.BaroAltitude != 0 || dal.VerticalRate == nil || *dal.VerticalRate != 0 {
+		t.Errorf("DAL9 = %d ft, vertical rate %v; want 0 ft, 0", dal.BaroAltitude, dal.VerticalRate)
+	}
+	flights, err := r.GetFlightsNear(context.Background(), "KSFO", Arriving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range flights {
+		if f.Ident == "DAL9" {
+			t.Error("GetFlightsNear returned DAL9, which is on the ground")
+		}
+	}
+	if len(flights) != 2 {
+		t.Errorf("GetFlightsNear returned %d flights, want UAL123 and SWA1", len(flights))
+	}
+}
+
+func TestReadsbStaleFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, readsbAircraftJSON, float64(time.Now().Add(-5*time.Minute).Unix()))
+	}))
+	defer srv.Close()
+
+	r := NewReadsbProvider(srv.URL)
+	if _, err := r.GetPositionsNear(context.Background(), "KSFO"); err == nil {
+		t.Error("GetPositionsNear served an aircraft.json five minutes old")
+	}
+}
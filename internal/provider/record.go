@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recording kinds.
+const (
+	recordFlights  = "flights"
+	recordPosition = "position"
+)
+
+// recordEntry is one line of a session recording (JSONL).
+type recordEntry struct {
+	Time      time.Time       `json:"time"`
+	Provider  string          `json:"provider"`
+	Kind      string          `json:"kind"` // "flights" or "position"
+	Airport   string          `json:"airport,omitempty"`
+	Direction FlightDirection `json:"direction"`
+	Flight    *Flight         `json:"flight,omitempty"` // position lookups: the flight asked for
+	Flights   []Flight        `json:"flights,omitempty"`
+	Position  *FlightPosition `json:"position,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// RecordingProvider wraps a FlightProvider and appends every GetFlightsNear and
+// GetFlightPosition result, with a timestamp, to a JSONL file. Play the file
+// back with ReplayProvider.
+type RecordingProvider struct {
+	inner FlightProvider
+
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecordingProvider wraps inner, appending results to the file at path.
+func NewRecordingProvider(inner FlightProvider, path string) (*RecordingProvider, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+	return &RecordingProvider{
+		inner: inner,
+		f:     f,
+		enc:   json.NewEncoder(f),
+	}, nil
+}
+
+// Name returns the wrapped provider's name so rate limits and logs are unaffected.
+func (r *RecordingProvider) Name() string { return r.inner.Name() }
+
+// Unwrap returns the wrapped provider.
+func (r *RecordingProvider) Unwrap() FlightProvider { return r.inner }
+
+// Health forwards the wrapped provider's health report, if it has one.
+func (r *RecordingProvider) Health() []ProviderHealth {
+	if hr, ok := r.inner.(HealthReporter); ok {
+		return hr.Health()
+	}
+	return nil
+}
+
+// GetPositionsNear forwards to the wrapped provider's bulk position query.
+// Bulk results are not recorded.
+func (r *RecordingProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	bp, ok := bulkPositions(r.inner)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", r.Name(), errNoBulkPositions)
+	}
+	return bp.GetPositionsNear(ctx, airportICAO)
+}
+
+// Close flushes and closes the recording file.
+func (r *RecordingProvider) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+func (r *RecordingProvider) write(ctx context.Context, e recordEntry) {
+	if ctx.Err() != nil {
+		return // lookups abandoned at shutdown aren't part of the session
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.Time = time.Now()
+	e.Provider = r.inner.Name()
+	if err := r.enc.Encode(e); err != nil {
+		// Recording is best-effort; never fail the live lookup.
+		log.Printf("[recorder] write error: %v", err)
+	}
+}
+
+// GetFlightsNear calls the wrapped provider and records the result.
+func (r *RecordingProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	flights, err := r.inner.GetFlightsNear(ctx, airportICAO, direction)
+	e := recordEntry{
+		Kind:      recordFlights,
+		Airport:   airportICAO,
+		Direction: direction,
+		Flights:   flights,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.write(ctx, e)
+	return flights, err
+}
+
+// GetFlightPosition calls the wrapped provider and records the result.
+func (r *RecordingProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	pos, err := r.inner.GetFlightPosition(ctx, flight)
+	asked := *flight
+	e := recordEntry{
+		Kind:     recordPosition,
+		Flight:   &asked,
+		Position: pos,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.write(ctx, e)
+	return pos, err
+}
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayProvider implements FlightProvider by playing back a recording made
+// with RecordingProvider.
+//
+// With a positive speed the recording is replayed against the wall clock
+// (1 = real time, 10 = ten times faster) and each call returns the latest
+// matching result recorded up to the current replay time. With speed 0 each
+// call returns the next matching result in recorded order, independent of
+// timing, which makes tracker runs deterministic.
+type ReplayProvider struct {
+	entries []recordEntry
+	speed   float64
+
+	// Loop restarts the recording from the beginning once it has been played through.
+	Loop bool
+
+	mu      sync.Mutex
+	started time.Time      // wall clock at the first call (timed mode)
+	next    map[string]int // per-lookup cursor (sequential mode)
+}
+
+// NewReplayProvider loads the recording at path. speed scales playback time;
+// 0 selects sequential playback.
+func NewReplayProvider(path string, speed float64) (*ReplayProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	defer f.Close()
+
+	var entries []recordEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024) // a busy flights line can be large
+	line := 0
+	for sc.Scan() {
+		line++
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var e recordEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("replay: %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("replay: %s has no entries", path)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	if speed < 0 {
+		speed = 0
+	}
+	return &ReplayProvider{
+		entries: entries,
+		speed:   speed,
+		next:    make(map[string]int),
+	}, nil
+}
+
+func (r *ReplayProvider) Name() string { return "replay" }
+
+// replayTime returns the recorded time the replay has reached, and the offset
+// to add to recorded timestamps to bring them to the present.
+// Must be called with mu held.
+func (r *ReplayProvider) replayTime() (time.Time, time.Duration) {
+	now := time.Now()
+	if r.started.IsZero() {
+		r.started = now
+	}
+	first := r.entries[0].Time
+	length := r.entries[len(r.entries)-1].Time.Sub(first)
+
+	elapsed := time.Duration(float64(now.Sub(r.started)) * r.speed)
+	if elapsed > length {
+		if !r.Loop || length <= 0 {
+			last := r.entries[len(r.entries)-1].Time
+			return last, now.Sub(last)
+		}
+		elapsed %= length
+	}
+	at := first.Add(elapsed)
+	return at, now.Sub(at)
+}
+
+// find returns the entry matching the predicate: the latest one up to the
+// replay time in timed mode, or the next one after cursor key in sequential mode.
+func (r *ReplayProvider) find(key string, match func(*recordEntry) bool) (*recordEntry, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.speed == 0 {
+		start := r.next[key]
+		for pass := 0; pass < 2; pass++ {
+			for i := start; i < len(r.entries); i++ {
+				if match(&r.entries[i]) {
+					r.next[key] = i + 1
+					return &r.entries[i], time.Since(r.entries[i].Time), nil
+				}
+			}
+			if !r.Loop || start == 0 {
+				break
+			}
+			start = 0
+		}
+		return nil, 0, errReplayExhausted
+	}
+
+	at, shift := r.replayTime()
+	end := sort.Search(len(r.entries), func(i int) bool {
+		return r.entries[i].Time.After(at)
+	})
+	for i := end - 1; i >= 0; i-- {
+		if match(&r.entries[i]) {
+			return &r.entries[i], shift, nil
+		}
+	}
+	return nil, 0, errReplayExhausted
+}
+
+var errReplayExhausted = errors.New("replay: no recorded result")
+
+// GetFlightsNear returns the recorded flights for the airport and direction.
+func (r *ReplayProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	key := fmt.Sprintf("%s/%s/%s", recordFlights, airportICAO, direction)
+	e, _, err := r.find(key, func(e *recordEntry) bool {
+		return e.Kind == recordFlights && e.Airport == airportICAO && e.Direction == direction
+	})
+	if err != nil {
+		return nil, err
+	}
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+
+	flights := make([]Flight, len(e.Flights))
+	copy(flights, e.Flights)
+	return flights, nil
+}
+
+// GetFlightPosition returns the recorded position for the flight, with its
+// timestamp shifted so it appears as fresh as it was when recorded.
+func (r *ReplayProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	key := fmt.Sprintf("%s/%s/%s", recordPosition, flight.Ident, flight.FlightID)
+	e, shift, err := r.find(key, func(e *recordEntry) bool {
+		if e.Kind != recordPosition || e.Flight == nil {
+			return false
+		}
+		if flight.FlightID != "" && e.Flight.FlightID == flight.FlightID {
+			return true
+		}
+		return flight.Ident != "" && e.Flight.Ident == flight.Ident
+	})
+	if err != nil {
+		return nil, fmt.Errorf("replay: no recorded position for %q: %w", flight.Ident, ErrNotFound)
+	}
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+	if e.Position == nil {
+		return nil, fmt.Errorf("replay: recorded no position for %q: %w", flight.Ident, ErrNotFound)
+	}
+
+	pos := *e.Position
+	if !pos.Timestamp.IsZero() {
+		pos.Timestamp = pos.Timestamp.Add(shift)
+	}
+	if !pos.LastContact.IsZero() {
+		pos.LastContact = pos.LastContact.Add(shift)
+	}
+	return &pos, nil
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubProvider returns fixed results.
+type stubProvider struct {
+	flights []Flight
+	pos     *FlightPosition
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	if direction == Departing {
+		return nil, errors.New("stub: departures unavailable")
+	}
+	return p.flights, nil
+}
+
+func (p *stubProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	if flight.Ident != "UAL123" {
+		return nil, nil
+	}
+	return p.pos, nil
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	vr := FeetPerMinute(-640)
+	heading := 280
+	stub := &stubProvider{
+		flights: []Flight{{Ident: "UAL123", FlightID: "a1b2c3", ICAO24: "a1b2c3", Category: "A3", IsAirborne: true}},
+		pos: &FlightPosition{
+			BaroAltitude: 4200,
+			GeoAltitude:  4350,
+			VerticalRate: &vr,
+			Groundspeed:  180,
+			Heading:      &heading,
+			Latitude:     37.7,
+			Longitude:    -122.3,
+			Squawk:       "1234",
+			Source:       SourceADSB,
+			Timestamp:    time.Now().Add(-2 * time.Second),
+		},
+	}
+
+	rec, err := NewRecordingProvider(stub, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.GetFlightsNear(ctx, "KSFO", Arriving)
+	rec.GetFlightsNear(ctx, "KSFO", Departing)
+	rec.GetFlightPosition(ctx, &stub.flights[0])
+	rec.GetFlightPosition(ctx, &Flight{Ident: "DAL9"})
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayProvider(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flights, err := replay.GetFlightsNear(ctx, "KSFO", Arriving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flights) != 1 || flights[0].Ident != "UAL123" || flights[0].Category != "A3" {
+		t.Errorf("replayed flights = %+v, want the recorded UAL123", flights)
+	}
+	if _, err := replay.GetFlightsNear(ctx, "KSFO", Departing); err == nil {
+		t.Error("replayed departures succeeded, want the recorded error")
+	}
+
+	pos, err := replay.GetFlightPosition(ctx, &Flight{Ident: "UAL123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *stub.pos
+	if pos.BaroAltitude != want.BaroAltitude || pos.GeoAltitude != want.GeoAltitude ||
+		pos.Groundspeed != want.Groundspeed || pos.Latitude != want.Latitude || pos.Longitude != want.Longitude ||
+		pos.Squawk != want.Squawk || pos.Source != want.Source {
+		t.Errorf("replayed position = %+v, want %+v", *pos, want)
+	}
+	if pos.VerticalRate == nil || *pos.VerticalRate != vr || pos.Heading == nil || *pos.Heading != heading {
+		t.Errorf("replayed rate/heading = %v/%v, want %d/%d", pos.VerticalRate, pos.Heading, vr, heading)
+	}
+	// Sequential replay shifts timestamps to now, keeping the recorded age.
+	if age := pos.Age(time.Now()); age < 2*time.Second || age > 5*time.Second {
+		t.Errorf("replayed position is %v old, want about 2s", age)
+	}
+
+	// The recorder saw a nil position for DAL9; replay reports it as not found.
+	if _, err := replay.GetFlightPosition(ctx, &Flight{Ident: "DAL9"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("replayed nil position: err = %v, want ErrNotFound", err)
+	}
+	// Nothing was recorded for this flight at all.
+	if _, err := replay.GetFlightPosition(ctx, &Flight{Ident: "AAL1"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unrecorded flight: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestReplayOldRecording(t *testing.T) {
+	// Recordings made before BaroAltitude name the pressure altitude
+	// "Altitude", in hundreds of feet: FL350 was written as 350.
+	path := filepath.Join(t.TempDir(), "old.jsonl")
+	line := `{"time":"2025-01-02T03:04:05Z","provider":"opensky","kind":"position","direction":0,` +
+		`"flight":{"Ident":"UAL123"},"position":{"Altitude":350,"AltitudeChange":"-","Groundspeed":450,` +
+		`"Heading":null,"Latitude":37.7,"Longitude":-122.3,"Timestamp":"2025-01-02T03:04:00Z"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The recorder appends, so a session recorded to the same file now
+	// follows it in the new format.
+	stub := &stubProvider{pos: &FlightPosition{BaroAltitude: 34000, Groundspeed: 440, Timestamp: time.Now()}}
+	rec, err := NewRecordingProvider(stub, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.GetFlightPosition(context.Background(), &Flight{Ident: "UAL123"})
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayProvider(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct {
+		alt Feet
+		gs  Knots
+	}{{35000, 450}, {34000, 440}} {
+		pos, err := replay.GetFlightPosition(context.Background(), &Flight{Ident: "UAL123"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos.BaroAltitude != want.alt || pos.Groundspeed != want.gs {
+			t.Errorf("replayed %d ft %d kt, want %d ft %d kt", pos.BaroAltitude, pos.Groundspeed, want.alt, want.gs)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Route is where a flight is flying from and to.
+type Route struct {
+	Origin      *AirportRef
+	Destination *AirportRef
+}
+
+// RouteProvider looks up a flight's route by callsign, for flights whose
+// provider reports position only (OpenSky, local receivers).
+type RouteProvider interface {
+	// Name returns a human-readable provider name for logging.
+	Name() string
+
+	// GetRoute returns the route flown under the flight's callsign. Errors
+	// wrap ErrNotFound when the provider answered but doesn't know it.
+	GetRoute(ctx context.Context, flight *Flight) (*Route, error)
+}
+
+// callsignOf returns the flight's ICAO callsign, e.g. "UAL123".
+func callsignOf(f *Flight) string {
+	if f.IdentICAO != "" {
+		return f.IdentICAO
+	}
+	return f.Ident
+}
+
+// RouteTable is a local table of routes by callsign, for operators that fly
+// the same schedule every day and flights no online source knows.
+type RouteTable struct {
+	routes map[string]Route // by upper-case callsign or IATA flight number
+}
+
+// LoadRouteTable reads a CSV file of callsign,origin,destination rows with a
+// header row, e.g. "UAL123,KSFO,KEWR". Airports may be ICAO or IATA codes;
+// the callsign may also be an IATA flight number such as "UA123".
+func LoadRouteTable(path string) (*RouteTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("routes: %w", err)
+	}
+	defer f.Close()
+
+	cr := csv.NewReader(f)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	if _, err := cr.Read(); err != nil { // header
+		return nil, fmt.Errorf("routes: %s: %w", path, err)
+	}
+	t := &RouteTable{routes: make(map[string]Route)}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return t, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("routes: %s: %w", path, err)
+		}
+		callsign := strings.ToUpper(strings.TrimSpace(rec[0]))
+		origin, dest := AirportRefFor(rec[1]), AirportRefFor(rec[2])
+		if callsign == "" || origin == nil || dest == nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("routes: %s: line %d: need a callsign and two airports", path, line)
+		}
+		t.routes[callsign] = Route{Origin: origin, Destination: dest}
+	}
+}
+
+func (t *RouteTable) Name() string { return "routes" }
+
+// Len returns the number of routes in the table.
+func (t *RouteTable) Len() int { return len(t.routes) }
+
+// GetRoute returns the table's route for the flight's callsign or IATA
+// flight number.
+func (t *RouteTable) GetRoute(ctx context.Context, flight *Flight) (*Route, error) {
+	for _, ident := range []string{callsignOf(flight), flight.IdentIATA} {
+		if ident == "" {
+			continue
+		}
+		if r, ok := t.routes[strings.ToUpper(ident)]; ok {
+			return &r, nil
+		}
+	}
+	return nil, fmt.Errorf("routes: %q: %w", callsignOf(flight), ErrNotFound)
+}
@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sbsDialTimeout = 10 * time.Second
+	sbsReadTimeout = 60 * time.Second // a healthy feed is never silent this long
+	sbsMaxBackoff  = 30 * time.Second
+)
+
+// SBSProvider implements FlightProvider from a local dump1090 SBS-1 BaseStation
+// feed (TCP port 30003). Aircraft state is assembled from MSG,1..8 records and
+// served from memory, so no API quota is used.
+type SBSProvider struct {
+	addr      string // host:port of the BaseStation feed
+	table     *aircraftTable
+	connected atomic.Bool
+}
+
+// NewSBSProvider creates a provider reading the BaseStation feed at addr
+// (e.g. "localhost:30003"). Call Run to start consuming the feed.
+func NewSBSProvider(addr string) *SBSProvider {
+	return &SBSProvider{
+		addr:  addr,
+		table: newAircraftTable(),
+	}
+}
+
+func (s *SBSProvider) Name() string { return "sbs" }
+
+// Run reads the feed, reconnecting with backoff whenever it drops.
+// Blocks until ctx is cancelled — run in a goroutine.
+func (s *SBSProvider) Run(ctx context.Context) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := s.readFeed(ctx)
+		s.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A connection that stayed up for a while resets the backoff.
+		if time.Since(start) > sbsMaxBackoff {
+			backoff = time.Second
+		}
+		log.Printf("[sbs] feed %s: %v, reconnecting in %v", s.addr, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > sbsMaxBackoff {
+			backoff = sbsMaxBackoff
+		}
+	}
+}
+
+// readFeed connects once and consumes lines until the connection fails.
+func (s *SBSProvider) readFeed(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: sbsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock the pending read when the context is cancelled.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	log.Printf("[sbs] connected to %s", s.addr)
+	s.connected.Store(true)
+
+	sc := bufio.NewScanner(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(sbsReadTimeout))
+		if !sc.Scan() {
+			break
+		}
+		s.handleLine(sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("connection closed")
+}
+
+// handleLine applies one BaseStation record to the aircraft table.
+//
+// Format: MSG,type,session,aircraft,hex,flight,dateGen,timeGen,dateLog,timeLog,
+// callsign,altitude,groundspeed,track,lat,lon,vertRate,squawk,alert,emergency,spi,onGround
+//
+// Each message type fills a different subset of fields; empty fields are left
+// untouched so the table accumulates the full picture across messages.
+func (s *SBSProvider) handleLine(line string) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 11 || fields[0] != "MSG" {
+		return
+	}
+	msgType, err := strconv.Atoi(fields[1])
+	if err != nil || msgType < 1 || msgType > 8 {
+		return
+	}
+	icao24 := fields[4]
+	if !isHexAddr(icao24) {
+		return
+	}
+
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	s.table.update(icao24, func(a *localAircraft) {
+		if cs := field(10); cs != "" {
+			a.callsign = cs
+		}
+		if alt, err := strconv.Atoi(field(11)); err == nil {
+			a.altitude = Feet(alt)
+		}
+		if gs, err := strconv.ParseFloat(field(12), 64); err == nil {
+			a.groundspeed = Knots(gs)
+		}
+		if trk, err := strconv.ParseFloat(field(13), 64); err == nil {
+			h := int(trk)
+			a.track = &h
+		}
+		lat, latErr := strconv.ParseFloat(field(14), 64)
+		lon, lonErr := strconv.ParseFloat(field(15), 64)
+		if latErr == nil && lonErr == nil {
+			a.lat, a.lon = lat, lon
+			a.hasPos = true
+			a.lastPos = time.Now()
+		}
+		if vr, err := strconv.Atoi(field(16)); err == nil {
+			a.vertRate = FeetPerMinute(vr)
+			a.hasVertRate = true
+		}
+		if sq := field(17); sq != "" {
+			a.squawk = sq
+		}
+		// dump1090 writes -1 for true; some feeders write 1.
+		switch field(21) {
+		case "-1", "1":
+			a.onGround = true
+		case "0":
+			a.onGround = false
+		}
+	})
+}
+
+// GetFlightsNear returns airborne aircraft heard around the airport.
+// The feed carries no route data, so direction is ignored.
+func (s *SBSProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	if !s.connected.Load() {
+		return nil, fmt.Errorf("sbs: not connected to %s", s.addr)
+	}
+	lat, lon := airportCoords(airportICAO)
+	return s.table.flightsNear(lat, lon, 1.0), nil
+}
+
+// GetPositionsNear returns every aircraft heard around the airport.
+func (s *SBSProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	if !s.connected.Load() {
+		return nil, fmt.Errorf("sbs: not connected to %s", s.addr)
+	}
+	lat, lon := airportCoords(airportICAO)
+	return s.table.snapshot(lat, lon, 1.0), nil
+}
+
+// GetFlightPosition returns the latest position heard for the flight.
+func (s *SBSProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	pos, ok := s.table.position(flight)
+	if !ok {
+		return nil, fmt.Errorf("sbs: %q not heard recently: %w", flight.Ident, ErrNotFound)
+	}
+	return pos, nil
+}
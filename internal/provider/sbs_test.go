@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sbsLine builds a BaseStation MSG record of the given type with the fields
+// at the given indexes set, as dump1090 writes it.
+func sbsLine(msgType, hex string, set map[int]string) string {
+	fields := make([]string, 22)
+	fields[0], fields[1], fields[2], fields[3] = "MSG", msgType, "1", "1"
+	fields[4], fields[5] = hex, "1"
+	fields[6], fields[7] = "2026/10/16", "12:00:00.000"
+	fields[8], fields[9] = "2026/10/16", "12:00:00.000"
+	for i, v := range set {
+		fields[i] = v
+	}
+	return strings.Join(fields, ",")
+}
+
+func TestSBSAssemblesMessages(t *testing.T) {
+	s := NewSBSProvider("localhost:30003")
+	s.connected.Store(true)
+
+	// Each message type carries part of the picture; together they make a
+	// complete flight.
+	for _, line := range []string{
+		sbsLine("1", "A1B2C3", map[int]string{10: "UAL123  ", 21: "0"}),
+		sbsLine("3", "A1B2C3", map[int]string{11: "12000", 14: "37.70000", 15: "-122.30000", 21: "0"}),
+		sbsLine("4", "A1B2C3", map[int]string{12: "250", 13: "90", 16: "-640"}),
+		sbsLine("6", "A1B2C3", map[int]string{17: "1234"}),
+		"MSG,3,1,1,NOTHEX,1,,,,,,5000,,,1.0,2.0", // bad address: ignored
+		"STA,,1,1,A1B2C3,1,,,,,",                 // not a MSG record: ignored
+	} {
+		s.handleLine(line)
+	}
+
+	flights, err := s.GetFlightsNear(context.Background(), "KSFO", Arriving)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flights) != 1 {
+		t.Fatalf("GetFlightsNear returned %d flights, want 1", len(flights))
+	}
+	f := flights[0]
+	if f.Ident != "UAL123" || f.ICAO24 != "a1b2c3" || !f.IsAirborne {
+		t.Errorf("flight = %q %q airborne=%v, want \"UAL123\" \"a1b2c3\" airborne", f.Ident, f.ICAO24, f.IsAirborne)
+	}
+
+	pos, err := s.GetFlightPosition(context.Background(), &Flight{Ident: "UAL123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.BaroAltitude != 12000 || pos.Groundspeed != 250 || pos.Latitude != 37.7 || pos.Longitude != -122.3 {
+		t.Errorf("position = %d ft %d kt %v,%v, want 12000 ft 250 kt 37.7,-122.3",
+			pos.BaroAltitude, pos.Groundspeed, pos.Latitude, pos.Longitude)
+	}
+	if pos.Heading == nil || *pos.Heading != 90 {
+		t.Errorf("heading = %v, want 90", pos.Heading)
+	}
+	if pos.VerticalRate == nil || *pos.VerticalRate != -640 || pos.Trend() != "D" {
+		t.Errorf("vertical rate = %v (%q), want -640 descending", pos.VerticalRate, pos.Trend())
+	}
+	if pos.Squawk != "1234" {
+		t.Errorf("squawk = %q, want 1234", pos.Squawk)
+	}
+}
+
+func TestSBSGroundFlag(t *testing.T) {
+	s := NewSBSProvider("localhost:30003")
+	s.connected.Store(true)
+
+	// dump1090 marks "on ground" with -1.
+	s.handleLine(sbsLine("1", "ABCDEF", map[int]string{10: "DAL9"}))
+	s.handleLine(sbsLine("2", "ABCDEF", map[int]string{14: "37.62", 15: "-122.38", 21: "-1"}))
+
+	flights, err := s.GetFlightsNear(context.Background(), "KSFO", Departing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flights) != 0 {
+		t.Errorf("GetFlightsNear returned %v, want no flights for an aircraft on the ground", flights)
+	}
+}
+
+func TestSBSNotConnected(t *testing.T) {
+	s := NewSBSProvider("localhost:30003")
+	if _, err := s.GetFlightsNear(context.Background(), "KSFO", Arriving); err == nil {
+		t.Error("GetFlightsNear succeeded without a feed connection")
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSBSFeedReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSBSProvider(ln.Addr().String())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The feed serves one flight and drops when told to, then serves another
+	// on the next connection.
+	drop := make(chan struct{})
+	go func() {
+		serve := func(hex, ident string) net.Conn {
+			conn, err := ln.Accept()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(conn, "%s\r\n%s\r\n",
+				sbsLine("1", hex, map[int]string{10: ident, 21: "0"}),
+				sbsLine("3", hex, map[int]string{11: "12000", 14: "37.7", 15: "-122.3", 21: "0"}))
+			return conn
+		}
+		first := serve("A1B2C3", "UAL123")
+		if first == nil {
+			return
+		}
+		<-drop
+		first.Close()
+		if second := serve("ABCDEF", "DAL9"); second != nil {
+			<-ctx.Done()
+			second.Close()
+		}
+	}()
+
+	hasFlight := func(ident string) func() bool {
+		return func() bool {
+			flights, err := s.GetFlightsNear(ctx, "KSFO", Arriving)
+			for _, f := range flights {
+				if err == nil && f.Ident == ident {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	waitFor(t, "the first flight", hasFlight("UAL123"))
+	close(drop)
+	waitFor(t, "the feed to drop", func() bool { return !s.connected.Load() })
+	waitFor(t, "the flight from the reconnected feed", hasFlight("DAL9"))
+}
@@ -0,0 +1,469 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/subham/flighttracker/internal/airlines"
+)
+
+const (
+	simDefaultAircraft = 40
+	simRadiusNM        = 55.0 // aircraft are spawned here and removed beyond simRemoveNM
+	simRemoveNM        = 62.0
+	simMaxStep         = 2 * time.Second // integration step
+	simTurnRate        = 3.0             // degrees per second (standard rate)
+	simGlideFtPerNM    = 318.0           // 3° glide path
+)
+
+// simRunway is a runway end, positioned relative to the airport reference point
+// so the same geometry can be moved to another center.
+type simRunway struct {
+	name       string
+	dLat, dLon float64 // threshold offset from the reference point, degrees
+	heading    float64 // true heading, degrees
+}
+
+// SFO runway ends in use for the usual west-flow configuration:
+// arrivals on 28L/28R, departures off 01L/01R (and 28s when busy).
+var (
+	simArrivalRunways = []simRunway{
+		{name: "28L", dLat: 37.6117 - 37.6213, dLon: -122.3582 + 122.3790, heading: 297.9},
+		{name: "28R", dLat: 37.6136 - 37.6213, dLon: -122.3572 + 122.3790, heading: 297.9},
+	}
+	simDepartureRunways = []simRunway{
+		{name: "01L", dLat: 37.6081 - 37.6213, dLon: -122.3829 + 122.3790, heading: 27.9},
+		{name: "01R", dLat: 37.6063 - 37.6213, dLon: -122.3811 + 122.3790, heading: 27.9},
+		{name: "28L", dLat: 37.6117 - 37.6213, dLon: -122.3582 + 122.3790, heading: 297.9},
+	}
+)
+
+// simRemoteAirports are the other ends of simulated routes, completed from
+// the airport database.
+var simRemoteAirports = []string{
+	"KLAX", "KJFK", "KORD", "KSEA", "KDEN", "PHNL", "RJAA", "EGLL",
+	"LFPG", "EDDF", "VHHH", "RKSI", "YSSY", "MMMX", "CYVR", "OMDB",
+}
+
+var simAircraftTypes = []string{"A320", "A321", "A21N", "B738", "B739", "B38M", "B772", "B77W", "B789", "A359", "E75L"}
+
+// simHeavyTypes broadcast emitter category A5; the rest A3.
+var simHeavyTypes = map[string]bool{"B772": true, "B77W": true, "B789": true, "A359": true}
+
+type simPhase int
+
+const (
+	simInbound  simPhase = iota // arrival heading for the approach fixes
+	simFinal                    // arrival established on final
+	simClimbout                 // departure on runway heading
+	simOutbound                 // departure turned on course
+	simDone
+)
+
+// simAircraft is one simulated aircraft.
+type simAircraft struct {
+	icao24    string
+	callsign  string
+	typeCode  string
+	squawk    string
+	remote    AirportRef
+	direction FlightDirection
+	phase     simPhase
+	runway    simRunway
+
+	lat, lon  float64
+	altFt     float64
+	gsKt      float64
+	heading   float64
+	vrateFPM  float64
+	targetAlt float64
+	targetGs  float64
+	exitBrg   float64 // departure course once clear of the airport
+	waypoints [][2]float64
+}
+
+// SimOptions configures a SimProvider.
+type SimOptions struct {
+	Aircraft int      // aircraft kept in the air (default 40)
+	Airlines []string // IATA codes to draw callsigns from; default: every active airline in the registry
+	Seed     int64    // random seed, 0 for time-based
+
+	// CenterLat/CenterLon move the simulated airport away from the real one,
+	// e.g. next to the antimeridian. Zero uses the requested airport's coordinates.
+	CenterLat, CenterLon float64
+}
+
+// SimProvider implements FlightProvider with synthetic arrivals and departures
+// flying the SFO runway layout: climbs, descents, turns onto final and out on
+// course at realistic speeds. It needs no network and uses no API budget.
+type SimProvider struct {
+	mu        sync.Mutex
+	rng       *rand.Rand
+	target    int
+	airlines  []string // ICAO designators
+	centered  bool
+	lat, lon  float64
+	airport   string
+	aircraft  []*simAircraft
+	populated bool
+	lastStep  time.Time
+	nextHex   int
+}
+
+// NewSimProvider creates a simulator with the given options.
+func NewSimProvider(opts SimOptions) *SimProvider {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	target := opts.Aircraft
+	if target <= 0 {
+		target = simDefaultAircraft
+	}
+
+	var designators []string
+	for _, iata := range opts.Airlines {
+		if a, ok := airlines.ByIATA(iata); ok && a.ICAO != "" {
+			designators = append(designators, a.ICAO)
+		}
+	}
+	if len(designators) == 0 {
+		for _, a := range airlines.Active() {
+			if !a.Cargo {
+				designators = append(designators, a.ICAO)
+			}
+		}
+	}
+
+	s := &SimProvider{
+		rng:      rand.New(rand.NewSource(seed)),
+		target:   target,
+		airlines: designators,
+		nextHex:  0xa00000,
+	}
+	if opts.CenterLat != 0 || opts.CenterLon != 0 {
+		s.lat, s.lon = opts.CenterLat, opts.CenterLon
+		s.centered = true
+	}
+	return s
+}
+
+func (s *SimProvider) Name() string { return "sim" }
+
+// GetFlightsNear advances the simulation and returns aircraft of the given direction.
+func (s *SimProvider) GetFlightsNear(ctx context.Context, airportICAO string, direction FlightDirection) ([]Flight, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.center(airportICAO)
+	s.advance(time.Now())
+
+	var flights []Flight
+	for _, a := range s.aircraft {
+		if a.direction == direction {
+			flights = append(flights, s.toFlight(a))
+		}
+	}
+	return flights, nil
+}
+
+// GetFlightPosition advances the simulation and returns the aircraft's position.
+func (s *SimProvider) GetFlightPosition(ctx context.Context, flight *Flight) (*FlightPosition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance(time.Now())
+	for _, a := range s.aircraft {
+		if a.icao24 == flight.TransponderHex() || a.callsign == flight.Ident {
+			pos := a.toPosition(s.lastStep)
+			return &pos, nil
+		}
+	}
+	return nil, fmt.Errorf("sim: %q has left the simulation: %w", flight.Ident, ErrNotFound)
+}
+
+// GetPositionsNear advances the simulation and returns every aircraft.
+func (s *SimProvider) GetPositionsNear(ctx context.Context, airportICAO string) (*AreaSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.center(airportICAO)
+	s.advance(time.Now())
+
+	snap := &AreaSnapshot{Box: boxAroundNM(s.lat, s.lon, simRemoveNM), FetchedAt: time.Now()}
+	for _, a := range s.aircraft {
+		snap.Flights = append(snap.Flights, s.toFlight(a))
+		snap.Positions = append(snap.Positions, a.toPosition(s.lastStep))
+	}
+	return snap, nil
+}
+
+// center fixes the simulated airport on first use.
+// Must be called with mu held.
+func (s *SimProvider) center(airportICAO string) {
+	if s.airport == "" {
+		s.airport = airportICAO
+	}
+	if !s.centered {
+		s.lat, s.lon = airportCoords(airportICAO)
+		s.centered = true
+	}
+}
+
+// advance steps the simulation up to now and tops traffic back up.
+// Must be called with mu held.
+func (s *SimProvider) advance(now time.Time) {
+	if !s.centered {
+		return
+	}
+	if s.lastStep.IsZero() {
+		s.lastStep = now
+	}
+	for now.Sub(s.lastStep) > 0 {
+		dt := now.Sub(s.lastStep)
+		if dt > simMaxStep {
+			dt = simMaxStep
+		}
+		for _, a := range s.aircraft {
+			s.step(a, dt.Seconds())
+		}
+		s.lastStep = s.lastStep.Add(dt)
+	}
+	s.removeDone()
+
+	for len(s.aircraft) < s.target {
+		a := s.spawn(s.rng.Intn(2) == 0)
+		// The initial population starts part-way through their flights so
+		// traffic is spread out instead of arriving in one wave.
+		if !s.populated {
+			warm := s.rng.Intn(900)
+			for t := 0; t < warm && a.phase != simDone; t += 2 {
+				s.step(a, 2)
+			}
+		}
+		if a.phase != simDone {
+			s.aircraft = append(s.aircraft, a)
+		}
+	}
+	s.populated = true
+}
+
+func (s *SimProvider) removeDone() {
+	kept := s.aircraft[:0]
+	for _, a := range s.aircraft {
+		if a.phase != simDone {
+			kept = append(kept, a)
+		}
+	}
+	s.aircraft = kept
+}
+
+// spawn creates a new arrival (at the edge of the area) or departure (on a runway).
+func (s *SimProvider) spawn(arrival bool) *simAircraft {
+	airline := s.airlines[s.rng.Intn(len(s.airlines))]
+	s.nextHex++
+	a := &simAircraft{
+		icao24:   fmt.Sprintf("%06x", s.nextHex&0xffffff),
+		callsign: fmt.Sprintf("%s%d", airline, 1+s.rng.Intn(2999)),
+		typeCode: simAircraftTypes[s.rng.Intn(len(simAircraftTypes))],
+		squawk:   fmt.Sprintf("%04o", 01000+s.rng.Intn(06000)), // clear of the emergency codes
+		remote:   *AirportRefFor(simRemoteAirports[s.rng.Intn(len(simRemoteAirports))]),
+	}
+
+	if arrival {
+		rwy := simArrivalRunways[s.rng.Intn(len(simArrivalRunways))]
+		brg := s.rng.Float64() * 360
+		a.direction = Arriving
+		a.phase = simInbound
+		a.runway = rwy
+		a.lat, a.lon = offsetNM(s.lat, s.lon, brg, simRadiusNM)
+		a.altFt = 12000 + s.rng.Float64()*6000
+		a.gsKt = 280 + s.rng.Float64()*40
+		a.targetGs = 250
+
+		// Initial approach fix 18nm out on the extended centerline, then final approach fix at 8nm.
+		thrLat, thrLon := s.threshold(rwy)
+		back := math.Mod(rwy.heading+180, 360)
+		iafLat, iafLon := offsetNM(thrLat, thrLon, back, 18)
+		fafLat, fafLon := offsetNM(thrLat, thrLon, back, 8)
+		a.waypoints = [][2]float64{{iafLat, iafLon}, {fafLat, fafLon}}
+		a.heading = bearingDeg(a.lat, a.lon, iafLat, iafLon)
+		a.targetAlt = 5000
+	} else {
+		rwy := simDepartureRunways[s.rng.Intn(len(simDepartureRunways))]
+		a.direction = Departing
+		a.phase = simClimbout
+		a.runway = rwy
+		a.lat, a.lon = s.threshold(rwy)
+		a.heading = rwy.heading
+		a.altFt = 0
+		a.gsKt = 150
+		a.targetGs = 250
+		a.targetAlt = 3000
+		a.exitBrg = s.rng.Float64() * 360
+	}
+	return a
+}
+
+// threshold returns where rwy begins around the current center, with the
+// longitude normalised to [-180, 180) like the aircraft positions.
+func (s *SimProvider) threshold(rwy simRunway) (float64, float64) {
+	return s.lat + rwy.dLat, normalizeLon(s.lon + rwy.dLon)
+}
+
+// step advances one aircraft by dt seconds.
+func (s *SimProvider) step(a *simAircraft, dt float64) {
+	thrLat, thrLon := s.threshold(a.runway)
+
+	// Phase logic sets the targets.
+	targetHdg := a.heading
+	switch a.phase {
+	case simInbound:
+		wp := a.waypoints[0]
+		targetHdg = bearingDeg(a.lat, a.lon, wp[0], wp[1])
+		if distanceNM(a.lat, a.lon, wp[0], wp[1]) < 1.5 {
+			a.waypoints = a.waypoints[1:]
+			if len(a.waypoints) == 0 {
+				a.phase = simFinal
+			} else {
+				a.targetAlt = 3000
+				a.targetGs = 180
+			}
+		}
+	case simFinal:
+		targetHdg = bearingDeg(a.lat, a.lon, thrLat, thrLon)
+		d := distanceNM(a.lat, a.lon, thrLat, thrLon)
+		a.targetAlt = math.Min(a.altFt, d*simGlideFtPerNM)
+		a.targetGs = 140
+		if d < 0.3 || a.altFt < 50 {
+			a.phase = simDone // landed
+			return
+		}
+	case simClimbout:
+		targetHdg = a.runway.heading
+		if a.altFt >= 2500 {
+			a.phase = simOutbound
+			a.targetAlt = 15000 + math.Floor(s.rng.Float64()*20)*1000
+		}
+	case simOutbound:
+		targetHdg = a.exitBrg
+		if a.altFt > 10000 {
+			a.targetGs = 420
+		}
+		if distanceNM(s.lat, s.lon, a.lat, a.lon) > simRemoveNM {
+			a.phase = simDone
+			return
+		}
+	}
+
+	// Turn toward the target heading at standard rate.
+	diff := math.Mod(targetHdg-a.heading+540, 360) - 180
+	maxTurn := simTurnRate * dt
+	if diff > maxTurn {
+		diff = maxTurn
+	} else if diff < -maxTurn {
+		diff = -maxTurn
+	}
+	a.heading = math.Mod(a.heading+diff+360, 360)
+
+	// Accelerate/decelerate at ~2 kt/s.
+	if dv := a.targetGs - a.gsKt; math.Abs(dv) > 2*dt {
+		a.gsKt += math.Copysign(2*dt, dv)
+	} else {
+		a.gsKt = a.targetGs
+	}
+
+	// Climb at 2500 ft/min, descend at 1800 ft/min (faster on a steep final).
+	oldAlt := a.altFt
+	rate := 2500.0
+	if a.targetAlt < a.altFt {
+		rate = 1800
+		if a.phase == simFinal {
+			rate = a.gsKt * simGlideFtPerNM / 60 * 1.5
+		}
+	}
+	if da := a.targetAlt - a.altFt; math.Abs(da) > rate*dt/60 {
+		a.altFt += math.Copysign(rate*dt/60, da)
+	} else {
+		a.altFt = a.targetAlt
+	}
+	a.vrateFPM = (a.altFt - oldAlt) / dt * 60
+
+	a.lat, a.lon = offsetNM(a.lat, a.lon, a.heading, a.gsKt*dt/3600)
+}
+
+func (s *SimProvider) toFlight(a *simAircraft) Flight {
+	f := Flight{
+		FlightID:     a.icao24,
+		ICAO24:       a.icao24,
+		AircraftType: a.typeCode,
+		Category:     "A3",
+		Status:       "En Route",
+		IsAirborne:   true,
+	}
+	if simHeavyTypes[a.typeCode] {
+		f.Category = "A5"
+	}
+	applyCallsign(&f, a.callsign)
+
+	home := AirportRefFor(s.airport)
+	remote := a.remote
+	if a.direction == Arriving {
+		f.Origin, f.Destination = &remote, home
+	} else {
+		f.Origin, f.Destination = home, &remote
+	}
+	return f
+}
+
+func (a *simAircraft) toPosition(at time.Time) FlightPosition {
+	h := int(math.Round(a.heading)) % 360
+	vr := FeetPerMinute(math.Round(a.vrateFPM))
+	return FlightPosition{
+		BaroAltitude: Feet(a.altFt),
+		VerticalRate: &vr,
+		Groundspeed:  Knots(a.gsKt),
+		Heading:      &h,
+		Latitude:     a.lat,
+		Longitude:    a.lon,
+		Squawk:       a.squawk,
+		Source:       SourceSimulated,
+		Timestamp:    at,
+		LastContact:  at,
+	}
+}
+
+// offsetNM returns the point dist nautical miles from (lat, lon) along the
+// given true bearing. Longitude is normalised to [-180, 180) so tracks
+// crossing the antimeridian wrap correctly.
+func offsetNM(lat, lon, bearing, dist float64) (float64, float64) {
+	const earthRadiusNM = 3440.065
+	lat1 := lat * math.Pi / 180
+	lon1 := lon * math.Pi / 180
+	brg := bearing * math.Pi / 180
+	ang := dist / earthRadiusNM
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(ang) + math.Cos(lat1)*math.Sin(ang)*math.Cos(brg))
+	lon2 := lon1 + math.Atan2(math.Sin(brg)*math.Sin(ang)*math.Cos(lat1), math.Cos(ang)-math.Sin(lat1)*math.Sin(lat2))
+
+	return lat2 * 180 / math.Pi, normalizeLon(lon2 * 180 / math.Pi)
+}
+
+// normalizeLon wraps a longitude into [-180, 180).
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// bearingDeg returns the initial true bearing from one point to another.
+func bearingDeg(lat1, lon1, lat2, lon2 float64) float64 {
+	p1 := lat1 * math.Pi / 180
+	p2 := lat2 * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(p2)
+	x := math.Cos(p1)*math.Sin(p2) - math.Sin(p1)*math.Cos(p2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
@@ -1,6 +1,12 @@
 package provider
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/subham/flighttracker/internal/airports"
+)
 
 // FlightDirection indicates whether a tracked flight is arriving or departing.
 type FlightDirection int
@@ -24,6 +30,54 @@ type AirportRef struct {
 	CodeIATA string
 	Name     string
 	City     string
+	Country  string // ISO 3166-1 alpha-2, e.g. "US"; empty if unknown
+}
+
+// AirportRefFor returns a reference to the airport with the given ICAO or
+// IATA code, with its name, city and country from the airport database when
+// it is known. It returns nil for an empty code.
+func AirportRefFor(code string) *AirportRef {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil
+	}
+	ref := &AirportRef{Code: code}
+	if len(code) == 3 {
+		ref.CodeIATA = code
+	} else {
+		ref.CodeICAO = code
+	}
+	completeAirport(ref)
+	return ref
+}
+
+// completeAirport fills a reference's missing codes, name, city and country
+// from the airport database. Fields the source provided are kept.
+func completeAirport(ref *AirportRef) {
+	if ref == nil {
+		return
+	}
+	a, ok := airports.ByICAO(ref.CodeICAO)
+	if !ok {
+		a, ok = airports.ByIATA(ref.CodeIATA)
+	}
+	if !ok {
+		a, ok = airports.Lookup(ref.Code)
+	}
+	if !ok {
+		return
+	}
+	fill := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	fill(&ref.CodeICAO, a.ICAO)
+	fill(&ref.CodeIATA, a.IATA)
+	fill(&ref.Code, a.ICAO)
+	fill(&ref.Name, a.Name)
+	fill(&ref.City, a.City)
+	fill(&ref.Country, a.Country)
 }
 
 // DisplayCode returns the best available airport code for display.
@@ -59,13 +113,75 @@ func (a *AirportRef) DisplayCity() string {
 
 // FlightPosition represents the current position of a flight.
 type FlightPosition struct {
-	Altitude       int
-	AltitudeChange string // "C" = climbing, "D" = descending, "-" = level
-	Groundspeed    int    // knots
-	Heading        *int
+	BaroAltitude Feet           // pressure altitude
+	GeoAltitude  Feet           // GNSS altitude, 0 if not reported
+	VerticalRate *FeetPerMinute // nil if not reported
+	// AltitudeChange is the trend ("C" = climbing, "D" = descending, "-" =
+	// level) from providers that report no numeric rate. Use Trend.
+	AltitudeChange string
+	Groundspeed    Knots
+	Heading        *int // degrees true
 	Latitude       float64
 	Longitude      float64
-	Timestamp      time.Time
+	Squawk         string         // transponder code, empty if unknown
+	Source         PositionSource // empty if unknown
+	Timestamp      time.Time      // when the position was measured
+	LastContact    time.Time      // when the aircraft was last heard from, zero if unknown
+}
+
+// UnmarshalJSON also reads recordings made before BaroAltitude, whose
+// Altitude field was in hundreds of feet.
+func (p *FlightPosition) UnmarshalJSON(data []byte) error {
+	type position FlightPosition // without this method
+	var v struct {
+		position
+		Altitude *int
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = FlightPosition(v.position)
+	if v.Altitude != nil && p.BaroAltitude == 0 {
+		p.BaroAltitude = Feet(*v.Altitude * 100)
+	}
+	return nil
+}
+
+// Trend returns "C" if the flight is climbing, "D" if descending, "-" if
+// level, or "" if unknown.
+func (p *FlightPosition) Trend() string {
+	if p.VerticalRate != nil {
+		return altitudeChangeFPM(*p.VerticalRate)
+	}
+	return p.AltitudeChange
+}
+
+// LastHeard returns when the aircraft was last heard from: LastContact if the
+// provider reports it, else when the position was measured.
+func (p *FlightPosition) LastHeard() time.Time {
+	if p.LastContact.After(p.Timestamp) {
+		return p.LastContact
+	}
+	return p.Timestamp
+}
+
+// Emergency returns what an emergency squawk means, e.g. "radio failure"
+// for 7600, or "" if the squawk isn't one.
+func (p *FlightPosition) Emergency() string {
+	switch p.Squawk {
+	case "7500":
+		return "hijack"
+	case "7600":
+		return "radio failure"
+	case "7700":
+		return "emergency"
+	}
+	return ""
+}
+
+// Age returns how old the position was at now.
+func (p *FlightPosition) Age(now time.Time) time.Duration {
+	return now.Sub(p.Timestamp)
 }
 
 // Flight represents a flight.
@@ -73,7 +189,10 @@ type Flight struct {
 	Ident          string
 	IdentICAO      string
 	IdentIATA      string
-	FlightID       string // provider-specific unique ID
+	FlightID       string          // provider-specific unique ID
+	ICAO24         string          // transponder hex address (lowercase), empty if unknown
+	Registration   string          // tail number, e.g. "N37281"
+	Category       EmitterCategory // ADS-B emitter category, e.g. "A3"
 	Operator       string
 	OperatorICAO   string
 	OperatorIATA   string
@@ -83,7 +202,24 @@ type Flight struct {
 	Status         string
 	AircraftType   string
 	IsAirborne     bool
+	AirlineName    string // operator's full name; filled in by enrichment
+	AircraftName   string // display name of the type, e.g. "Boeing 787-9"; filled in by enrichment
 	SourceProvider string // name of the provider that discovered this flight
+
+	// Provenance maps a field name (e.g. "Origin") to the provider that
+	// supplied it. Only set on flights fused from several providers.
+	Provenance map[string]string
+	// SourceIDs maps a provider name to that provider's FlightID for fused
+	// flights, so position lookups can use the ID each provider understands.
+	SourceIDs map[string]string
+}
+
+// FieldSource returns the name of the provider that supplied the given field.
+func (f *Flight) FieldSource(field string) string {
+	if src, ok := f.Provenance[field]; ok {
+		return src
+	}
+	return f.SourceProvider
 }
 
 // DisplayIdent returns the best flight identifier for display (prefers IATA).
@@ -110,3 +246,28 @@ func (f *Flight) OperatorName() string {
 	}
 	return f.Ident
 }
+
+// TransponderHex returns the flight's ICAO24 address, falling back to a
+// FlightID that looks like one. Empty if unknown.
+func (f *Flight) TransponderHex() string {
+	if isHexAddr(f.ICAO24) {
+		return strings.ToLower(f.ICAO24)
+	}
+	if isHexAddr(f.FlightID) {
+		return strings.ToLower(f.FlightID)
+	}
+	return ""
+}
+
+// isHexAddr returns true if the string looks like a 6-char ICAO24 hex address.
+func isHexAddr(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, c := range s {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
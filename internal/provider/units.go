@@ -0,0 +1,71 @@
+package provider
+
+import "math"
+
+// Feet is an altitude in feet.
+type Feet int
+
+// Knots is a speed in knots.
+type Knots int
+
+// FeetPerMinute is a vertical rate; positive is climbing.
+type FeetPerMinute int
+
+// Conversions from the SI units OpenSky and AviationStack report.
+const (
+	feetPerMetre        = 3.28084
+	knotsPerMetreSecond = 1.94384
+)
+
+func metresToFeet(m float64) Feet { return Feet(math.Round(m * feetPerMetre)) }
+
+func metresPerSecToKnots(v float64) Knots { return Knots(math.Round(v * knotsPerMetreSecond)) }
+
+func metresPerSecToFPM(v float64) FeetPerMinute {
+	return FeetPerMinute(math.Round(v * feetPerMetre * 60))
+}
+
+// PositionSource is the surveillance technique behind a position report.
+type PositionSource string
+
+const (
+	SourceADSB      PositionSource = "adsb"      // broadcast by the aircraft
+	SourceMLAT      PositionSource = "mlat"      // multilateration from several receivers
+	SourceTISB      PositionSource = "tisb"      // rebroadcast by ground stations (TIS-B, ADS-R)
+	SourceRadar     PositionSource = "radar"     // ATC radar, e.g. OpenSky's ASTERIX feed
+	SourceFLARM     PositionSource = "flarm"     // gliders and light aircraft
+	SourceSatellite PositionSource = "satellite" // space-based ADS-B or ADS-C
+	SourceEstimated PositionSource = "estimated" // projected from the flight plan
+	SourceSimulated PositionSource = "simulated"
+)
+
+// EmitterCategory is the ADS-B emitter category an aircraft broadcasts, e.g.
+// "A3" for a large airliner. Empty if unknown.
+type EmitterCategory string
+
+var emitterCategories = map[EmitterCategory]string{
+	"A1": "Light",
+	"A2": "Small",
+	"A3": "Large",
+	"A4": "High vortex",
+	"A5": "Heavy",
+	"A6": "High performance",
+	"A7": "Rotorcraft",
+	"B1": "Glider",
+	"B2": "Lighter than air",
+	"B3": "Parachutist",
+	"B4": "Ultralight",
+	"B6": "UAV",
+	"B7": "Space vehicle",
+	"C1": "Emergency vehicle",
+	"C2": "Service vehicle",
+	"C3": "Obstacle",
+	"C4": "Obstacle",
+	"C5": "Obstacle",
+}
+
+// Description returns a short name for the category, e.g. "Heavy", or "" if
+// the category is unknown or reserved.
+func (c EmitterCategory) Description() string {
+	return emitterCategories[c]
+}
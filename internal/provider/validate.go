@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// maxImpliedKnots is Mach 1 at sea level. No airliner gets near it, so a
+	// position that implies more is a different aircraft or a bad fix.
+	maxImpliedKnots = 661
+	// maxClimbFPM is a vertical rate no airliner reaches, even in an
+	// emergency descent.
+	maxClimbFPM = 15000
+	// maxAltitude is above any airliner's or business jet's ceiling.
+	maxAltitude Feet = 60000
+
+	// Slack for noise between providers: fixes a few seconds apart can
+	// differ by this much without either being wrong.
+	positionSlackNM   = 1.0
+	altitudeSlackFeet = 1000
+	// areaMarginDeg is how far outside the query area a bulk result may be.
+	areaMarginDeg = 0.25
+
+	// validatorReanchor is how many positions in a row may be rejected for a
+	// flight before the newest is accepted anyway, so one bad fix accepted
+	// first can't lock out every good one after it.
+	validatorReanchor = 3
+	// validatorForget is how long a flight's last position is compared with.
+	validatorForget = 10 * time.Minute
+)
+
+// positionValidator rejects physically impossible position updates: fixes
+// that imply supersonic speed or an impossible climb since the flight's last
+// accepted position, whichever provider reported it.
+type positionValidator struct {
+	mu      sync.Mutex
+	flights map[string]*validatedFlight // by validatorKey
+}
+
+type validatedFlight struct {
+	last       FlightPosition
+	rejected   int       // consecutive motion rejections
+	rejectedAt time.Time // timestamp of the newest rejected fix
+	reason     error     // why it was rejected
+}
+
+// seen returns the timestamp of the newest fix judged for the flight.
+func (vf *validatedFlight) seen() time.Time {
+	if vf.rejectedAt.After(vf.last.Timestamp) {
+		return vf.rejectedAt
+	}
+	return vf.last.Timestamp
+}
+
+// rejectedBefore is returned when a fix that was already rejected is checked
+// again, as positions from a cached snapshot are on every tick. The fix is
+// still refused, but it is not counted again.
+type rejectedBefore struct{ reason error }
+
+func (e rejectedBefore) Error() string { return e.reason.Error() }
+func (e rejectedBefore) Unwrap() error { return e.reason }
+
+func newPositionValidator() *positionValidator {
+	return &positionValidator{flights: make(map[string]*validatedFlight)}
+}
+
+// validatorKey identifies an aircraft across providers. The callsign comes
+// first: every provider reports it, while AeroAPI has no transponder address.
+func validatorKey(f *Flight) string {
+	if f.IdentICAO != "" {
+		return f.IdentICAO
+	}
+	if f.Ident != "" {
+		return f.Ident
+	}
+	return f.TransponderHex()
+}
+
+// check returns an error describing why pos is implausible for flight, or
+// nil and records pos as the flight's latest. box is the area pos was
+// queried for, zero if none. Each fix is judged once: one no newer than the
+// flight's latest accepted fix passes, and one rejected before is refused
+// with a rejectedBefore error.
+func (v *positionValidator) check(flight *Flight, pos *FlightPosition, box BoundingBox) error {
+	key := validatorKey(flight)
+	if key == "" || pos.Timestamp.IsZero() {
+		if err := checkPlausible(pos); err != nil {
+			return err
+		}
+		return checkInArea(box, pos)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	vf, ok := v.flights[key]
+	if !ok {
+		vf = &validatedFlight{}
+		v.flights[key] = vf
+	}
+	switch {
+	case !pos.Timestamp.After(vf.last.Timestamp):
+		return nil // not newer; the tracker keeps the newer one
+	case pos.Timestamp.Equal(vf.rejectedAt):
+		return rejectedBefore{vf.reason}
+	}
+
+	reject := func(err error) error {
+		if pos.Timestamp.After(vf.rejectedAt) {
+			vf.rejectedAt, vf.reason = pos.Timestamp, err
+		}
+		return err
+	}
+	if err := checkPlausible(pos); err != nil {
+		return reject(err)
+	}
+	if err := checkInArea(box, pos); err != nil {
+		return reject(err)
+	}
+	anchored := !vf.last.Timestamp.IsZero() && pos.Timestamp.Sub(vf.last.Timestamp) <= validatorForget
+	if anchored {
+		if err := checkMotion(&vf.last, pos); err != nil {
+			vf.rejected++
+			if vf.rejected < validatorReanchor {
+				return reject(err)
+			}
+		}
+	}
+	vf.last = *pos
+	vf.rejected = 0
+	return nil
+}
+
+// prune drops flights not updated within validatorForget.
+func (v *positionValidator) prune(now time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for key, vf := range v.flights {
+		if now.Sub(vf.seen()) > validatorForget {
+			delete(v.flights, key)
+		}
+	}
+}
+
+// checkPlausible checks a position on its own.
+func checkPlausible(pos *FlightPosition) error {
+	switch {
+	case pos.Latitude < -90 || pos.Latitude > 90 || pos.Longitude < -180 || pos.Longitude > 180:
+		return fmt.Errorf("coordinates %.4f,%.4f out of range", pos.Latitude, pos.Longitude)
+	case pos.Latitude == 0 && pos.Longitude == 0:
+		return fmt.Errorf("null island position")
+	case pos.BaroAltitude > maxAltitude:
+		return fmt.Errorf("altitude %d ft above %d ft", pos.BaroAltitude, maxAltitude)
+	}
+	return nil
+}
+
+// checkMotion checks the move from prev to pos, which is newer.
+func checkMotion(prev, pos *FlightPosition) error {
+	dt := pos.Timestamp.Sub(prev.Timestamp)
+	hours := math.Max(dt.Hours(), 1.0/3600) // at least a second
+
+	dist := distanceNM(prev.Latitude, prev.Longitude, pos.Latitude, pos.Longitude)
+	if knots := (dist - positionSlackNM) / hours; knots > maxImpliedKnots {
+		return fmt.Errorf("jumped %.1fnm in %v (%.0fkt implied)", dist, dt.Round(time.Second), knots)
+	}
+
+	if prev.BaroAltitude != 0 && pos.BaroAltitude != 0 {
+		climb := math.Abs(float64(pos.BaroAltitude - prev.BaroAltitude))
+		if fpm := (climb - altitudeSlackFeet) / (hours * 60); fpm > maxClimbFPM {
+			return fmt.Errorf("altitude jumped %d → %d ft in %v", prev.BaroAltitude, pos.BaroAltitude, dt.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// checkInArea returns an error if pos lies outside the box it was queried
+// for, allowing areaMarginDeg.
+func checkInArea(box BoundingBox, pos *FlightPosition) error {
+	if box == (BoundingBox{}) {
+		return nil
+	}
+	grown := BoundingBox{
+		LatMin: box.LatMin - areaMarginDeg, LonMin: box.LonMin - areaMarginDeg,
+		LatMax: box.LatMax + areaMarginDeg, LonMax: box.LonMax + areaMarginDeg,
+	}
+	if !grown.Contains(pos.Latitude, pos.Longitude) {
+		return fmt.Errorf("position %.4f,%.4f outside query area %v", pos.Latitude, pos.Longitude, box)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidatorJudgesEachFixOnce(t *testing.T) {
+	v := newPositionValidator()
+	flight := &Flight{Ident: "UAL123"}
+	t0 := time.Now().Add(-time.Minute)
+
+	good := &FlightPosition{Latitude: 37.7, Longitude: -122.3, BaroAltitude: 5000, Timestamp: t0}
+	if err := v.check(flight, good, BoundingBox{}); err != nil {
+		t.Fatalf("first fix rejected: %v", err)
+	}
+
+	// 60nm away ten seconds later: rejected once, then refused without being
+	// counted however often a cached snapshot repeats it.
+	bad := &FlightPosition{Latitude: 38.7, Longitude: -122.3, BaroAltitude: 5000, Timestamp: t0.Add(10 * time.Second)}
+	err := v.check(flight, bad, BoundingBox{})
+	var again rejectedBefore
+	if err == nil || errors.As(err, &again) {
+		t.Fatalf("bad fix: err = %v, want a new rejection", err)
+	}
+	for i := 0; i < 2*validatorReanchor; i++ {
+		if err := v.check(flight, bad, BoundingBox{}); !errors.As(err, &again) {
+			t.Fatalf("repeated bad fix, check %d: err = %v, want rejectedBefore", i, err)
+		}
+	}
+	if got := v.flights["UAL123"].last.Timestamp; !got.Equal(t0) {
+		t.Errorf("validator re-anchored on a repeated bad fix at %v", got)
+	}
+
+	// The good fix seen again is not newer than the anchor, so it passes.
+	if err := v.check(flight, good, BoundingBox{}); err != nil {
+		t.Errorf("repeated good fix: %v", err)
+	}
+}
+
+func TestValidatorReanchorsOnDistinctFixes(t *testing.T) {
+	v := newPositionValidator()
+	flight := &Flight{Ident: "UAL123"}
+	t0 := time.Now().Add(-time.Minute)
+	v.check(flight, &FlightPosition{Latitude: 37.7, Longitude: -122.3, Timestamp: t0}, BoundingBox{})
+
+	// The first fix was the bad one: new fixes keep disagreeing with it, so
+	// after validatorReanchor of them the newest is accepted.
+	var err error
+	for i := 1; i <= validatorReanchor; i++ {
+		pos := &FlightPosition{Latitude: 38.7, Longitude: -122.3, Timestamp: t0.Add(time.Duration(i) * time.Second)}
+		err = v.check(flight, pos, BoundingBox{})
+	}
+	if err != nil {
+		t.Errorf("fix %d after a bad anchor still rejected: %v", validatorReanchor, err)
+	}
+}
+
+func TestValidatorArea(t *testing.T) {
+	v := newPositionValidator()
+	box := BoundingBox{LatMin: 37, LonMin: -123, LatMax: 38, LonMax: -122}
+	pos := &FlightPosition{Latitude: 40, Longitude: -122.5, Timestamp: time.Now()}
+	if err := v.check(&Flight{Ident: "DAL9"}, pos, box); err == nil {
+		t.Error("position outside the query area accepted")
+	}
+}
@@ -0,0 +1,185 @@
+// Package station defines the airport a tracker kiosk watches: the code used
+// for provider queries, the radar centre and radius, and the display label.
+package station
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subham/flighttracker/internal/airports"
+)
+
+// Station is one watched airport and its radar zone.
+type Station struct {
+	Code     string  // ICAO airport code used for provider queries, e.g. "KSFO"
+	Label    string  // short display label, e.g. "SFO"
+	Lat, Lon float64 // radar centre
+	RadiusNM float64 // radar radius in nautical miles
+	Zoom     float64 // map tile zoom, 0 = derived from RadiusNM
+
+	// Airport is the station's entry in the airport database: elevation,
+	// time zone and runways. It is zero for a custom station.
+	Airport airports.Airport
+}
+
+// defaultRadiusNM is the radar radius of stations resolved from the airport
+// database.
+const defaultRadiusNM = 50.0
+
+// groups are named sets of stations watched together.
+var groups = map[string][]string{
+	"BAY": {"KSFO", "KOAK", "KSJC"}, // Bay Area
+}
+
+var (
+	mu         sync.RWMutex
+	registered = make(map[string]Station) // custom stations, by ICAO code
+)
+
+// Default returns the station used when none is configured: SFO.
+func Default() Station {
+	s, _ := Lookup("KSFO")
+	return s
+}
+
+// Lookup returns the station for an ICAO or IATA code (e.g. "KOAK" or
+// "OAK"). Registered stations take precedence; any airport in the airport
+// database resolves to a station centred on it with the default radius.
+func Lookup(code string) (Station, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	mu.RLock()
+	s, ok := registered[code]
+	mu.RUnlock()
+	if !ok {
+		var a airports.Airport
+		if a, ok = airports.Lookup(code); ok {
+			s = Station{Code: a.ICAO, Label: a.Label(), Lat: a.Lat, Lon: a.Lon, Airport: a}
+		}
+	}
+	if ok && s.RadiusNM == 0 {
+		s.RadiusNM = defaultRadiusNM
+	}
+	return s, ok
+}
+
+// LookupList resolves a comma-separated list of codes or labels, or a group
+// name such as "BAY", into stations. The first station is the primary one.
+func LookupList(spec string) ([]Station, error) {
+	codes := strings.Split(spec, ",")
+	if group, ok := groups[strings.ToUpper(strings.TrimSpace(spec))]; ok {
+		codes = group
+	}
+	var out []Station
+	for _, code := range codes {
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		s, ok := Lookup(code)
+		if !ok {
+			return nil, fmt.Errorf("station: unknown airport %q", strings.TrimSpace(code))
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("station: no airports in %q", spec)
+	}
+	return out, nil
+}
+
+// Span returns a view covering every station's radar zone: centred between
+// them, with a radius reaching the far edge of each. It is for display only;
+// its Code is empty.
+func Span(stations []Station) Station {
+	if len(stations) == 1 {
+		return stations[0]
+	}
+	latMin, latMax := math.Inf(1), math.Inf(-1)
+	lonMin, lonMax := math.Inf(1), math.Inf(-1)
+	labels := make([]string, len(stations))
+	for i, s := range stations {
+		latMin, latMax = math.Min(latMin, s.Lat), math.Max(latMax, s.Lat)
+		lonMin, lonMax = math.Min(lonMin, s.Lon), math.Max(lonMax, s.Lon)
+		labels[i] = s.Label
+	}
+	span := Station{
+		Label: strings.Join(labels, " / "),
+		Lat:   (latMin + latMax) / 2,
+		Lon:   (lonMin + lonMax) / 2,
+	}
+	for _, s := range stations {
+		span.RadiusNM = math.Max(span.RadiusNM, span.DistanceNM(s.Lat, s.Lon)+s.RadiusNM)
+	}
+	return span
+}
+
+// Nearest returns the index of the station closest to lat/lon whose radar
+// zone contains it, or -1 if none does.
+func Nearest(stations []Station, lat, lon float64) int {
+	best, bestDist := -1, math.Inf(1)
+	for i, s := range stations {
+		if d := s.DistanceNM(lat, lon); d <= s.RadiusNM && d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// Register makes s available to Lookup, so providers resolve a configured
+// station's centre and radius, including one the airport database lacks.
+func Register(s Station) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered[strings.ToUpper(s.Code)] = s
+}
+
+// MapZoom returns the tile zoom that fits the radar radius on the map:
+// zoom 10 shows about 50nm, and each zoom level halves the distance.
+func (s Station) MapZoom() float64 {
+	if s.Zoom > 0 {
+		return s.Zoom
+	}
+	if s.RadiusNM <= 0 {
+		return 10
+	}
+	return math.Round(10 + math.Log2(defaultRadiusNM/s.RadiusNM))
+}
+
+// LocalTime returns t in the station's time zone. ok is false when the
+// airport database doesn't know the zone.
+func (s Station) LocalTime(t time.Time) (local time.Time, ok bool) {
+	if s.Airport.TZ == "" {
+		return t, false
+	}
+	return t.In(s.Airport.Location()), true
+}
+
+// DistanceNM returns the distance from the station to lat/lon.
+func (s Station) DistanceNM(lat, lon float64) float64 {
+	return DistanceNM(s.Lat, s.Lon, lat, lon)
+}
+
+// InRange reports whether lat/lon is inside the radar radius.
+func (s Station) InRange(lat, lon float64) bool {
+	return s.DistanceNM(lat, lon) <= s.RadiusNM
+}
+
+func (s Station) String() string {
+	return fmt.Sprintf("%s (%s) %.4f,%.4f r=%.0fnm", s.Label, s.Code, s.Lat, s.Lon, s.RadiusNM)
+}
+
+// DistanceNM computes the great-circle distance between two lat/lon points in
+// nautical miles.
+func DistanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusNM = 3440.065
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return earthRadiusNM * c
+}
@@ -0,0 +1,361 @@
+package tracker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/subham/flighttracker/internal/provider"
+)
+
+// enrichForget is how long enrichment for a flight that is no longer
+// reported is kept before it is dropped.
+const enrichForget = time.Hour
+
+// An enricher that fails for a flight is retried after enrichRetryMin,
+// doubling with each failure in a row up to enrichRetryMax.
+const (
+	enrichRetryMin = 30 * time.Second
+	enrichRetryMax = 30 * time.Minute
+)
+
+// Enricher adds detail to a flight from a source other than the flight
+// provider, such as a local database or a per-flight API.
+type Enricher interface {
+	Name() string
+
+	// Enrich returns what it knows about the flight. f is the pipeline's
+	// private copy, with earlier enrichers' results already applied. It runs
+	// off the radar loop, so it may block on I/O.
+	//
+	// An empty result with a nil error means the enricher knows nothing about
+	// the flight, and it isn't asked again. An error means it couldn't find
+	// out, e.g. a network failure, and it is asked again later.
+	Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error)
+}
+
+// Reloadable is implemented by enrichers backed by data that is loaded or
+// replaced while the tracker runs, such as the aircraft registry. Whenever
+// Generation changes, the enricher is run again for every flight.
+type Reloadable interface {
+	Generation() int
+}
+
+// Enrichment is what an Enricher found out about a flight. Empty fields are
+// unknown.
+type Enrichment struct {
+	AirlineName  string
+	OperatorICAO string
+	OperatorIATA string
+	AircraftType string
+	AircraftName string
+	Registration string
+	Origin       *provider.AirportRef
+	Destination  *provider.AirportRef
+}
+
+// merge fills e's empty fields from o, so earlier results win.
+func (e *Enrichment) merge(o Enrichment) {
+	fill(&e.AirlineName, o.AirlineName)
+	fill(&e.OperatorICAO, o.OperatorICAO)
+	fill(&e.OperatorIATA, o.OperatorIATA)
+	fill(&e.AircraftType, o.AircraftType)
+	fill(&e.AircraftName, o.AircraftName)
+	fill(&e.Registration, o.Registration)
+	e.Origin = mergeAirport(e.Origin, o.Origin)
+	e.Destination = mergeAirport(e.Destination, o.Destination)
+}
+
+// apply fills the flight's empty fields. What the provider reported wins.
+// Airport references are replaced, never written through, since flights
+// share them with provider caches.
+func (e Enrichment) apply(f *provider.Flight) {
+	fill(&f.AirlineName, e.AirlineName)
+	fill(&f.OperatorICAO, e.OperatorICAO)
+	fill(&f.OperatorIATA, e.OperatorIATA)
+	fill(&f.AircraftType, e.AircraftType)
+	fill(&f.AircraftName, e.AircraftName)
+	fill(&f.Registration, e.Registration)
+	f.Origin = mergeAirport(f.Origin, e.Origin)
+	f.Destination = mergeAirport(f.Destination, e.Destination)
+}
+
+func fill(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
+
+// mergeAirport returns dst with its empty fields filled from src when both
+// refer to the same airport, or src if dst is nil. dst is copied, not
+// modified.
+func mergeAirport(dst, src *provider.AirportRef) *provider.AirportRef {
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		return src
+	}
+	if !sameAirport(dst, src) {
+		return dst
+	}
+	merged := *dst
+	fill(&merged.Code, src.Code)
+	fill(&merged.CodeICAO, src.CodeICAO)
+	fill(&merged.CodeIATA, src.CodeIATA)
+	fill(&merged.Name, src.Name)
+	fill(&merged.City, src.City)
+	fill(&merged.Country, src.Country)
+	if merged == *dst {
+		return dst
+	}
+	return &merged
+}
+
+// sameAirport reports whether two references name the same airport, comparing
+// the codes both of them have.
+func sameAirport(a, b *provider.AirportRef) bool {
+	if a.CodeICAO != "" && b.CodeICAO != "" {
+		return a.CodeICAO == b.CodeICAO
+	}
+	if a.CodeIATA != "" && b.CodeIATA != "" {
+		return a.CodeIATA == b.CodeIATA
+	}
+	return a.DisplayCode() == b.DisplayCode()
+}
+
+// StageOptions configures one enricher in a Pipeline.
+type StageOptions struct {
+	Every        time.Duration // minimum time between calls, 0 for no limit
+	FeaturedOnly bool          // only enrich the featured flight, e.g. for paid lookups
+}
+
+// Pipeline runs enrichers over the tracker's flights in the background.
+// Each flight passes through the enrichers in the order they were added, and
+// each enricher runs once per flight unless it fails, its data is reloaded,
+// or an earlier enricher later finds out more about the flight. Every
+// enricher has its own worker, so a slow or rate-limited one doesn't hold up
+// the others.
+type Pipeline struct {
+	stages []*stage
+
+	mu      sync.Mutex
+	entries map[string]*enrichEntry // by enrichKey
+	publish func(key string, e Enrichment)
+}
+
+type stage struct {
+	Enricher
+	StageOptions
+	in chan *enrichJob
+}
+
+// generation returns the enricher's data generation, 0 if it has none.
+func (s *stage) generation() int {
+	if r, ok := s.Enricher.(Reloadable); ok {
+		return r.Generation()
+	}
+	return 0
+}
+
+// enrichEntry is the pipeline's record of one flight.
+type enrichEntry struct {
+	result Enrichment
+	stages []stageRun // per stage
+	busy   bool       // a job for the flight is queued or running
+	seen   time.Time
+}
+
+// stageRun is one stage's progress on a flight.
+type stageRun struct {
+	done       bool      // ran without error, whether or not it found anything
+	generation int       // the enricher's generation when it ran
+	failures   int       // errors in a row
+	retryAt    time.Time // earliest retry after an error
+}
+
+// needed reports whether the stage should see the flight.
+func (r *stageRun) needed(s *stage, featured bool, now time.Time) bool {
+	switch {
+	case s.FeaturedOnly && !featured:
+		return false
+	case r.done:
+		return r.generation != s.generation()
+	default:
+		return !now.Before(r.retryAt)
+	}
+}
+
+// retryDelay returns how long to wait before retrying after the given number
+// of failures in a row.
+func retryDelay(failures int) time.Duration {
+	d := enrichRetryMin
+	for i := 1; i < failures && d < enrichRetryMax; i++ {
+		d *= 2
+	}
+	return min(d, enrichRetryMax)
+}
+
+type enrichJob struct {
+	key      string
+	flight   provider.Flight
+	featured bool
+}
+
+// NewPipeline returns an empty pipeline. Add enrichers before the tracker runs.
+func NewPipeline() *Pipeline {
+	return &Pipeline{entries: make(map[string]*enrichEntry)}
+}
+
+// Add appends an enricher to the pipeline.
+func (p *Pipeline) Add(e Enricher, opts StageOptions) {
+	p.stages = append(p.stages, &stage{Enricher: e, StageOptions: opts, in: make(chan *enrichJob, 64)})
+}
+
+// enrichKey identifies a flight across ticks, as the featured flight is.
+func enrichKey(f *provider.Flight) string {
+	if f.Ident != "" {
+		return f.Ident
+	}
+	return f.FlightID
+}
+
+// run starts a worker per enricher; publish is called with the flight's
+// accumulated enrichment after each enricher that finds something.
+func (p *Pipeline) run(ctx context.Context, publish func(key string, e Enrichment)) {
+	p.mu.Lock()
+	p.publish = publish
+	p.mu.Unlock()
+	for i, s := range p.stages {
+		go p.work(ctx, i, s)
+	}
+}
+
+// lookup returns the enrichment gathered so far for a flight.
+func (p *Pipeline) lookup(f *provider.Flight) (Enrichment, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[enrichKey(f)]
+	if !ok {
+		return Enrichment{}, false
+	}
+	return e.result, true
+}
+
+// submit queues a flight for the enrichers that haven't seen it yet. A full
+// queue drops the flight; it is submitted again next tick.
+func (p *Pipeline) submit(f *provider.Flight, featured bool) {
+	key := enrichKey(f)
+	if key == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.entries[key]
+	if !ok {
+		e = &enrichEntry{stages: make([]stageRun, len(p.stages))}
+		p.entries[key] = e
+	}
+	e.seen = time.Now()
+	if e.busy {
+		return
+	}
+	job := &enrichJob{key: key, flight: *f, featured: featured}
+	e.result.apply(&job.flight)
+	p.dispatch(e, job, 0)
+}
+
+// dispatch sends job to the first stage from start on that still needs to
+// see the flight. Must be called with mu held.
+func (p *Pipeline) dispatch(e *enrichEntry, job *enrichJob, start int) {
+	now := time.Now()
+	for i := start; i < len(p.stages); i++ {
+		s := p.stages[i]
+		if !e.stages[i].needed(s, job.featured, now) {
+			continue
+		}
+		select {
+		case s.in <- job:
+			e.busy = true
+		default:
+			e.busy = false
+		}
+		return
+	}
+	e.busy = false
+}
+
+// forget drops flights that haven't been submitted for a while.
+func (p *Pipeline) forget(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, e := range p.entries {
+		if !e.busy && now.Sub(e.seen) > enrichForget {
+			delete(p.entries, key)
+		}
+	}
+}
+
+func (p *Pipeline) work(ctx context.Context, idx int, s *stage) {
+	var last time.Time
+	for {
+		var job *enrichJob
+		select {
+		case <-ctx.Done():
+			return
+		case job = <-s.in:
+		}
+
+		if wait := time.Until(last.Add(s.Every)); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+		last = time.Now()
+
+		gen := s.generation()
+		res, err := s.Enrich(ctx, &job.flight)
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[enrich] %s: %s: %v", s.Name(), job.key, err)
+		}
+
+		p.mu.Lock()
+		e, ok := p.entries[job.key]
+		if !ok {
+			p.mu.Unlock()
+			continue
+		}
+		run := &e.stages[idx]
+		if err != nil {
+			run.failures++
+			run.retryAt = time.Now().Add(retryDelay(run.failures))
+		} else {
+			*run = stageRun{done: true, generation: gen}
+		}
+		var publish func(string, Enrichment)
+		if before := e.result; err == nil && res != (Enrichment{}) {
+			e.result.merge(res)
+			if e.result != before {
+				res.apply(&job.flight)
+				publish = p.publish
+				// Later stages may find more with what this one added.
+				for i := idx + 1; i < len(e.stages); i++ {
+					e.stages[i].done = false
+				}
+			}
+		}
+		result := e.result
+		p.dispatch(e, job, idx+1)
+		p.mu.Unlock()
+
+		if publish != nil {
+			publish(job.key, result)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subham/flighttracker/internal/aircraft"
+	"github.com/subham/flighttracker/internal/airlines"
+	"github.com/subham/flighttracker/internal/provider"
+)
+
+// AirlineEnricher names the operator and fills its codes from the airline
+// registry.
+type AirlineEnricher struct{}
+
+func (AirlineEnricher) Name() string { return "airline" }
+
+func (AirlineEnricher) Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error) {
+	a, ok := airlines.ByICAO(f.OperatorICAO)
+	if !ok {
+		a, ok = airlines.ByIATA(f.OperatorIATA)
+	}
+	if !ok && f.Operator != "" {
+		if a, ok = airlines.ByName(f.Operator); !ok {
+			a, ok = airlines.ByICAO(f.Operator) // AeroAPI reports the designator
+		}
+	}
+	if !ok {
+		return Enrichment{}, nil
+	}
+	return Enrichment{AirlineName: a.Name, OperatorICAO: a.ICAO, OperatorIATA: a.IATA}, nil
+}
+
+// AircraftEnricher fills the type, model and registration from the offline
+// aircraft registry by transponder address.
+type AircraftEnricher struct {
+	DB *aircraft.DB
+}
+
+func (e *AircraftEnricher) Name() string { return "aircraft" }
+
+// Generation changes when the registry loads, which happens in the
+// background after the tracker has started.
+func (e *AircraftEnricher) Generation() int { return e.DB.Generation() }
+
+func (e *AircraftEnricher) Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error) {
+	ac, ok := e.DB.Lookup(f.TransponderHex())
+	if !ok {
+		return Enrichment{}, nil
+	}
+	name := ac.Model
+	if ac.Manufacturer != "" && name != "" && !strings.HasPrefix(strings.ToLower(name), strings.ToLower(ac.Manufacturer)) {
+		name = ac.Manufacturer + " " + name
+	}
+	return Enrichment{AircraftType: ac.TypeCode, AircraftName: name, Registration: ac.Registration}, nil
+}
+
+// RouteEnricher fills origin and destination for flights whose provider
+// reports position only, asking each source in turn by callsign. Answers,
+// including "not found" from every source, are cached per callsign for the
+// rest of the UTC day, since airlines reuse a callsign for the same route.
+type RouteEnricher struct {
+	Sources []provider.RouteProvider
+
+	mu    sync.Mutex
+	day   string                     // UTC date the cache is for
+	cache map[string]*provider.Route // by callsign; nil if no source knows it
+}
+
+func (e *RouteEnricher) Name() string { return "route" }
+
+func (e *RouteEnricher) Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error) {
+	callsign := f.IdentICAO
+	if callsign == "" {
+		callsign = f.Ident
+	}
+	if (f.Origin != nil && f.Destination != nil) || callsign == "" {
+		return Enrichment{}, nil
+	}
+
+	route, cached := e.cached(callsign)
+	if !cached {
+		var err error
+		if route, err = e.resolve(ctx, f); err != nil {
+			return Enrichment{}, err
+		}
+		e.store(callsign, route)
+	}
+	if route == nil {
+		return Enrichment{}, nil
+	}
+	return Enrichment{Origin: route.Origin, Destination: route.Destination}, nil
+}
+
+// resolve asks each source in turn. It returns nil if every source answered
+// without knowing the route, or the last error if any source failed, so a
+// transient failure isn't cached as unknown.
+func (e *RouteEnricher) resolve(ctx context.Context, f *provider.Flight) (*provider.Route, error) {
+	var lastErr error
+	for _, src := range e.Sources {
+		route, err := src.GetRoute(ctx, f)
+		if err == nil && route != nil && (route.Origin != nil || route.Destination != nil) {
+			log.Printf("[enrich] route for %s from %s: %s → %s", f.DisplayIdent(), src.Name(),
+				route.Origin.DisplayCode(), route.Destination.DisplayCode())
+			return route, nil
+		}
+		if err != nil && !errors.Is(err, provider.ErrNotFound) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+func (e *RouteEnricher) cached(callsign string) (*provider.Route, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.day != utcDay(time.Now()) {
+		return nil, false
+	}
+	route, ok := e.cache[callsign]
+	return route, ok
+}
+
+func (e *RouteEnricher) store(callsign string, route *provider.Route) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if day := utcDay(time.Now()); e.day != day {
+		e.day = day
+		e.cache = make(map[string]*provider.Route)
+	}
+	e.cache[callsign] = route
+}
+
+func utcDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// CountryEnricher completes the route's airports, including the country used
+// for the flag, from the airport database.
+type CountryEnricher struct{}
+
+func (CountryEnricher) Name() string { return "country" }
+
+func (CountryEnricher) Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error) {
+	return Enrichment{Origin: completeRef(f.Origin), Destination: completeRef(f.Destination)}, nil
+}
+
+// completeRef returns the database's reference for the same airport as ref,
+// or nil if ref is nil, already has a country, or the airport is unknown.
+func completeRef(ref *provider.AirportRef) *provider.AirportRef {
+	if ref == nil || ref.Country != "" {
+		return nil
+	}
+	for _, code := range []string{ref.CodeICAO, ref.CodeIATA, ref.Code} {
+		if full := provider.AirportRefFor(code); full != nil && full.Country != "" {
+			return full
+		}
+	}
+	return nil
+}
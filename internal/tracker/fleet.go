@@ -1,6 +1,7 @@
-package ui
+package tracker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/subham/flighttracker/internal/provider"
 )
 
 const aerolopaBaseURL = "https://www.aerolopa.com/dummyversion/v1/airlines"
@@ -38,80 +41,93 @@ type fleetResponse struct {
 // fleetCache stores aircraft type lookups per airline slug.
 var fleetCache sync.Map // map[string]map[string]string (slug → (code → type))
 
-// fleetFetching tracks in-progress fetches to avoid duplicates.
-var fleetFetching sync.Map
-
 // cacheDir returns the fleet cache directory path.
 func cacheDir() string {
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".flighttracker", "cache", "airlines")
 }
 
-// LookupAircraftType returns the aircraft type display name for a given airline and aircraft code.
-// It triggers a background fetch if the data isn't cached yet.
-func LookupAircraftType(airlineIATA string, aircraftCode string) string {
+// FleetEnricher names the aircraft type as the operator lists it in its fleet
+// on aerolopa, e.g. "B789" as "Boeing 787-9 Dreamliner". Fleets are cached in
+// memory and on disk per airline. It leaves flights alone that already have
+// an aircraft name, so it is a network fallback for the aircraft registry.
+type FleetEnricher struct{}
+
+func (FleetEnricher) Name() string { return "fleet" }
+
+func (FleetEnricher) Enrich(ctx context.Context, f *provider.Flight) (Enrichment, error) {
+	if f.AircraftName != "" {
+		return Enrichment{}, nil
+	}
+	name, err := lookupFleetType(ctx, f.OperatorIATA, f.AircraftType)
+	if err != nil {
+		return Enrichment{}, err
+	}
+	return Enrichment{AircraftName: name}, nil
+}
+
+// lookupFleetType returns the aircraft type display name for a given airline
+// and aircraft code, fetching the airline's fleet if it isn't cached yet. It
+// returns an error only if the fleet couldn't be fetched and may be later.
+func lookupFleetType(ctx context.Context, airlineIATA string, aircraftCode string) (string, error) {
 	slug := strings.ToLower(airlineIATA)
 	if slug == "" || aircraftCode == "" {
-		return ""
+		return "", nil
 	}
 
-	// Check in-memory cache first
-	if cached, ok := fleetCache.Load(slug); ok {
-		if typeMap, ok := cached.(map[string]string); ok {
-			codeUpper := strings.ToUpper(aircraftCode)
-			if t, ok := typeMap[codeUpper]; ok {
-				return t
-			}
-			// Try partial match (e.g. "B738" → look for entries containing "738")
-			for code, typeName := range typeMap {
-				if strings.Contains(codeUpper, code) || strings.Contains(code, codeUpper) {
-					return typeName
-				}
-			}
+	if _, ok := fleetCache.Load(slug); !ok && !loadFromDisk(slug) {
+		if err := fetchFleetData(ctx, slug); err != nil {
+			return "", err
 		}
-		return "" // cached but no match
 	}
 
-	// Try loading from disk cache
-	if loadFromDisk(slug) {
-		return LookupAircraftType(airlineIATA, aircraftCode) // retry after loading
+	cached, ok := fleetCache.Load(slug)
+	if !ok {
+		return "", nil
 	}
-
-	// Trigger background fetch
-	go fetchFleetData(slug)
-	return ""
-}
-
-// fetchFleetData fetches fleet data from the aerolopa API and caches it.
-func fetchFleetData(slug string) {
-	// Prevent duplicate fetches
-	if _, loaded := fleetFetching.LoadOrStore(slug, true); loaded {
-		return
+	typeMap, _ := cached.(map[string]string)
+	codeUpper := strings.ToUpper(aircraftCode)
+	if t, ok := typeMap[codeUpper]; ok {
+		return t, nil
+	}
+	// Try partial match (e.g. "B738" → look for entries containing "738")
+	for code, typeName := range typeMap {
+		if strings.Contains(codeUpper, code) || strings.Contains(code, codeUpper) {
+			return typeName, nil
+		}
 	}
-	defer fleetFetching.Delete(slug)
+	return "", nil // cached but no match
+}
 
+// fetchFleetData fetches fleet data from the aerolopa API and caches it. An
+// airline aerolopa doesn't have is cached as an empty fleet; network and
+// server errors are returned uncached, so the pipeline retries them.
+func fetchFleetData(ctx context.Context, slug string) error {
 	apiURL := fmt.Sprintf("%s/%s", aerolopaBaseURL, slug)
 	client := &http.Client{Timeout: 10 * time.Second}
 
-	resp, err := client.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
-		log.Printf("[fleet] error fetching %s: %v", slug, err)
-		fleetCache.Store(slug, map[string]string{}) // cache empty to avoid retries
-		return
+		return fmt.Errorf("fleet: creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fleet: fetching %s: %w", slug, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("fleet: HTTP %d for %s", resp.StatusCode, slug)
+	}
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[fleet] HTTP %d for %s", resp.StatusCode, slug)
 		fleetCache.Store(slug, map[string]string{})
-		return
+		return nil
 	}
 
 	data, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
 	if err != nil {
-		log.Printf("[fleet] read error for %s: %v", slug, err)
-		fleetCache.Store(slug, map[string]string{})
-		return
+		return fmt.Errorf("fleet: reading %s: %w", slug, err)
 	}
 
 	// Parse and build type map
@@ -119,7 +135,7 @@ func fetchFleetData(slug string) {
 	if err := json.Unmarshal(data, &fleet); err != nil {
 		log.Printf("[fleet] parse error for %s: %v", slug, err)
 		fleetCache.Store(slug, map[string]string{})
-		return
+		return nil
 	}
 
 	typeMap := buildTypeMap(&fleet)
@@ -128,6 +144,7 @@ func fetchFleetData(slug string) {
 
 	// Save to disk
 	saveToDisk(slug, data)
+	return nil
 }
 
 // buildTypeMap creates a mapping of aircraft code → display type from fleet data.
@@ -0,0 +1,166 @@
+package tracker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
+)
+
+const (
+	nearAirportNM = 15.0 // featured flight on approach/departure: poll faster
+	fastKnots     = 300  // featured flight at cruise speed: poll a little faster
+)
+
+// Scheduler decides how long to wait between radar ticks. It polls faster
+// while the featured flight is close to the airport or moving quickly, backs
+// off when the sky is quiet, and never polls faster than the providers'
+// remaining quota can sustain over the active hours left in their window.
+type Scheduler struct {
+	Base time.Duration // interval with normal traffic and ample quota
+	Min  time.Duration // never poll faster than this
+	Max  time.Duration // never wait longer than this, unless quota needs it
+
+	// ActiveFrom and ActiveTo are the local hours (0-23) the display is
+	// watched, e.g. 6 and 23. Quota is spread over these hours only and
+	// polling slows to Max outside them. Equal values mean always active.
+	ActiveFrom, ActiveTo int
+
+	quietTicks int // consecutive ticks with no flights
+}
+
+// NewScheduler returns a scheduler with the default intervals, always active.
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		Base: pollInterval,
+		Min:  3 * time.Second,
+		Max:  2 * time.Minute,
+	}
+}
+
+// Next returns the delay before the next tick given the state the last tick
+// produced, and a short reason for logs and the UI.
+func (s *Scheduler) Next(now time.Time, st State) (time.Duration, string) {
+	if !s.isActive(now) {
+		return s.Max, "outside active hours"
+	}
+
+	delay, reason := s.Base, "normal"
+
+	// Traffic: speed up for an interesting featured flight, slow down when idle.
+	if len(st.AllFlights) == 0 {
+		s.quietTicks++
+		delay = s.Base * time.Duration(1<<min(s.quietTicks, 4))
+		reason = "quiet"
+	} else {
+		s.quietTicks = 0
+		if pos := featuredPosition(st); pos != nil {
+			near := station.Nearest(st.Stations, pos.Latitude, pos.Longitude)
+			switch {
+			case near >= 0 && st.Stations[near].DistanceNM(pos.Latitude, pos.Longitude) < nearAirportNM:
+				delay, reason = s.Base/2, "featured near airport"
+			case pos.Groundspeed >= fastKnots:
+				delay, reason = s.Base*3/4, "featured moving fast"
+			}
+		}
+	}
+
+	delay = min(max(delay, s.Min), s.Max)
+
+	// Quota: don't outrun what the best available provider can sustain. Max
+	// doesn't apply; a small quota may need longer gaps to last its window.
+	if pace := s.quotaPace(now, st.Providers, len(st.Stations)); pace > delay {
+		delay, reason = pace, "conserving quota"
+	}
+	return delay, reason
+}
+
+// quotaPace returns the shortest tick interval any usable provider can keep
+// up until its quota refreshes, counting only active hours. A tick's
+// discovery calls can only use the discovery share of the window, so that
+// share, not the whole remainder, sets the pace unless the featured poll
+// runs out first. Zero means some usable provider is unlimited.
+func (s *Scheduler) quotaPace(now time.Time, providers []provider.ProviderHealth, stations int) time.Duration {
+	discovery := discoveryCallsPerTick(stations)
+	var best time.Duration
+	found := false
+	for _, p := range providers {
+		if p.State == provider.BreakerOpen {
+			continue
+		}
+		if p.Remaining < 0 {
+			return 0
+		}
+		pace := s.activeWithin(now, p.Window) // nothing left: wait out the window
+		if ticks := min(p.DiscoveryRemaining/discovery, p.Remaining/(discovery+1)); ticks > 0 {
+			pace /= time.Duration(ticks)
+		}
+		if !found || pace < best {
+			best, found = pace, true
+		}
+	}
+	return best
+}
+
+// discoveryCallsPerTick is the discovery requests one radar tick makes: both
+// directions at every station. The tick also makes one featured position poll.
+func discoveryCallsPerTick(stations int) int {
+	return 2 * max(stations, 1)
+}
+
+// isActive reports whether t falls within the active hours.
+func (s *Scheduler) isActive(t time.Time) bool {
+	if s.ActiveFrom == s.ActiveTo {
+		return true
+	}
+	h := t.Hour()
+	if s.ActiveFrom < s.ActiveTo {
+		return h >= s.ActiveFrom && h < s.ActiveTo
+	}
+	return h >= s.ActiveFrom || h < s.ActiveTo // wraps past midnight
+}
+
+// activeWithin returns how much of the span [from, from+d) is within the
+// active hours.
+func (s *Scheduler) activeWithin(from time.Time, d time.Duration) time.Duration {
+	if s.ActiveFrom == s.ActiveTo {
+		return d
+	}
+	var active time.Duration
+	end := from.Add(d)
+	for t := from; t.Before(end); {
+		next := t.Truncate(time.Hour).Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+		if s.isActive(t) {
+			active += next.Sub(t)
+		}
+		t = next
+	}
+	return active
+}
+
+// featuredPosition returns the featured flight's position, if known.
+func featuredPosition(st State) *provider.FlightPosition {
+	if st.Featured == nil || st.Featured.Position == nil {
+		return nil
+	}
+	pos := st.Featured.Position
+	if pos.Latitude == 0 && pos.Longitude == 0 {
+		return nil
+	}
+	return pos
+}
+
+// ParseActiveHours parses an "HH-HH" local hour range such as "6-23".
+func ParseActiveHours(s string) (from, to int, err error) {
+	if _, err := fmt.Sscanf(s, "%d-%d", &from, &to); err != nil {
+		return 0, 0, fmt.Errorf("active hours %q: want HH-HH: %w", s, err)
+	}
+	if from < 0 || from > 23 || to < 0 || to > 24 {
+		return 0, 0, fmt.Errorf("active hours %q: hours must be 0-24", s)
+	}
+	return from, to % 24, nil
+}
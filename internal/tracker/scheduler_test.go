@@ -0,0 +1,50 @@
+package tracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
+)
+
+func TestSchedulerQuotaPaceBeyondMax(t *testing.T) {
+	s := NewScheduler()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	st := State{
+		Stations:   []station.Station{station.Default()},
+		AllFlights: []FlightWithPos{{Flight: &provider.Flight{Ident: "UAL123"}}},
+		// A monthly quota nearly spent, with 30 days to go.
+		Providers: []provider.ProviderHealth{{
+			Name: "monthly", Remaining: 600, DiscoveryRemaining: 225, Window: 30 * 24 * time.Hour,
+		}},
+	}
+
+	delay, reason := s.Next(now, st)
+	if reason != "conserving quota" {
+		t.Errorf("reason = %q, want conserving quota", reason)
+	}
+	// Ticks make two discovery calls, so the discovery share lasts 112 ticks
+	// though the whole remainder would last 200.
+	if want := 30 * 24 * time.Hour / 112; delay != want {
+		t.Errorf("delay = %v, want %v (longer than Max %v)", delay, want, s.Max)
+	}
+}
+
+func TestSchedulerClampsTrafficDelays(t *testing.T) {
+	s := NewScheduler()
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	st := State{
+		Stations:  []station.Station{station.Default()},
+		Providers: []provider.ProviderHealth{{Name: "local", Remaining: -1, DiscoveryRemaining: -1}},
+	}
+
+	// Quiet ticks back off, but never past Max.
+	var delay time.Duration
+	for i := 0; i < 10; i++ {
+		delay, _ = s.Next(now, st)
+	}
+	if delay != s.Max {
+		t.Errorf("quiet delay = %v, want Max %v", delay, s.Max)
+	}
+}
@@ -1,36 +1,49 @@
 package tracker
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"log"
-	"math"
-	"net/http"
 	"sync"
 	"time"
 
 	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
 )
 
-const (
-	airportCode  = "KSFO"
-	pollInterval = 8 * time.Second // how often we refresh the flight list + featured position
-	maxDistNM    = 50.0            // radar radius in nautical miles
-)
+const pollInterval = 8 * time.Second // default refresh interval; see Scheduler
+
+// stalePosition is how old a position can be before the flight is shown as
+// stale. Positions carry the time the provider observed them, which for a
+// cached or slow source can be well before the poll.
+const stalePosition = time.Minute
+
+// positionForget is how long a flight's last known position is shown once
+// its providers stop reporting one.
+const positionForget = 5 * time.Minute
 
 // FlightWithPos bundles a flight with its latest known position.
 type FlightWithPos struct {
 	Flight   *provider.Flight
 	Position *provider.FlightPosition
+	Airport  string // Code of the watched station the flight relates to
+	Stale    bool   // Position is older than stalePosition
 }
 
 // State holds the radar snapshot for the UI.
 type State struct {
-	AllFlights    []FlightWithPos // every flight in the radar zone
-	Featured      *FlightWithPos  // the one shown in the sidebar
-	FeaturedIdent string          // ident of the featured flight (for identity)
+	Stations      []station.Station // watched airports, primary first
+	AllFlights    []FlightWithPos   // every flight in any radar zone
+	Featured      *FlightWithPos    // the one shown in the sidebar
+	FeaturedIdent string            // ident of the featured flight (for identity)
 	Error         string
 	UpdatedAt     time.Time
+
+	// Providers is the per-provider health report, if the provider supports it.
+	Providers []provider.ProviderHealth
+
+	NextPoll   time.Time // when the scheduler expects the next refresh
+	PollReason string    // why the scheduler chose that interval
 }
 
 // Tracker manages the radar-style flight tracking.
@@ -45,9 +58,25 @@ type Tracker struct {
 	staleCount    int    // consecutive polls where featured was stationary
 	direction     provider.FlightDirection
 
+	// positions is the newest position seen per flight, by enrichKey, so an
+	// older result from another provider or a cache never replaces it.
+	// Only touched by the radar loop.
+	positions map[string]*provider.FlightPosition
+
 	// AirlineFilter is an optional callback that returns true if the airline
 	// code/name is known. Flights failing this check are skipped.
 	AirlineFilter func(iata, name string) bool
+
+	// Scheduler decides the interval between refreshes. Set before Run.
+	Scheduler *Scheduler
+
+	// Stations are the airports watched and their radar zones, primary
+	// first. Set before Run.
+	Stations []station.Station
+
+	// Enrich adds airline, aircraft and route detail to flights in the
+	// background. Set before Run; nil disables enrichment.
+	Enrich *Pipeline
 }
 
 // New creates a new Tracker with the given flight provider.
@@ -55,9 +84,17 @@ func New(prov provider.FlightProvider) *Tracker {
 	return &Tracker{
 		prov:      prov,
 		direction: provider.Departing,
+		positions: make(map[string]*provider.FlightPosition),
+		Scheduler: NewScheduler(),
+		Stations:  []station.Station{station.Default()},
 	}
 }
 
+// Home returns the primary station.
+func (t *Tracker) Home() station.Station {
+	return t.Stations[0]
+}
+
 // GetState returns a copy of the current tracking state (thread-safe).
 func (t *Tracker) GetState() State {
 	t.mu.RLock()
@@ -69,23 +106,61 @@ func (t *Tracker) setState(s State) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	s.UpdatedAt = time.Now()
+	s.Stations = t.Stations
 	t.state = s
 }
 
-// Run starts the radar loop. Blocks forever — run in a goroutine.
-func (t *Tracker) Run() {
+// refreshHealth copies the provider health report into the state.
+func (t *Tracker) refreshHealth() {
+	hr, ok := t.prov.(provider.HealthReporter)
+	if !ok {
+		return
+	}
+	health := hr.Health()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Providers = health
+}
+
+// Run starts the radar loop. Blocks until ctx is cancelled — run in a goroutine.
+func (t *Tracker) Run(ctx context.Context) {
+	if t.Enrich != nil {
+		t.Enrich.run(ctx, t.applyEnrichment)
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var lastReason string
 	for {
-		t.radarTick()
-		time.Sleep(pollInterval)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		t.radarTick(ctx)
+
+		now := time.Now()
+		delay, reason := t.Scheduler.Next(now, t.GetState())
+		if reason != lastReason {
+			log.Printf("[tracker] polling every %v (%s)", delay.Round(time.Second), reason)
+			lastReason = reason
+		}
+		t.mu.Lock()
+		t.state.NextPoll = now.Add(delay)
+		t.state.PollReason = reason
+		t.mu.Unlock()
+		timer.Reset(delay)
 	}
 }
 
 // radarTick performs one refresh cycle:
-// 1. Fetch all nearby flights (alternating departures/arrivals)
+// 1. Fetch all nearby flights at every station (alternating departures/arrivals)
 // 2. Filter to known airlines within radar range
 // 3. Poll position for the featured flight
 // 4. Manage featured flight selection
-func (t *Tracker) radarTick() {
+// 5. Fill in positions for the rest of the radar in bulk
+func (t *Tracker) radarTick(ctx context.Context) {
+	defer t.refreshHealth()
+
 	// Alternate direction each tick to get both arrivals and departures
 	if t.direction == provider.Departing {
 		t.direction = provider.Arriving
@@ -93,38 +168,19 @@ func (t *Tracker) radarTick() {
 		t.direction = provider.Departing
 	}
 
-	flights, err := t.prov.GetFlightsNear(airportCode, t.direction)
+	// Discovery is best-effort; the featured flight's polls draw on reserved quota.
+	discoverCtx := provider.WithPriority(ctx, provider.PriorityDiscovery)
+	featuredCtx := provider.WithPriority(ctx, provider.PriorityFeatured)
+
+	flights, airports, err := t.discover(discoverCtx)
 	if err != nil {
+		if ctx.Err() != nil {
+			return // shutting down
+		}
 		log.Printf("[tracker] error fetching flights: %v", err)
 		return
 	}
 
-	// Also fetch the other direction
-	otherDir := provider.Departing
-	if t.direction == provider.Departing {
-		otherDir = provider.Arriving
-	}
-	otherFlights, err2 := t.prov.GetFlightsNear(airportCode, otherDir)
-	if err2 == nil {
-		flights = append(flights, otherFlights...)
-	}
-
-	// Deduplicate by ident
-	seen := make(map[string]bool)
-	var deduped []provider.Flight
-	for _, f := range flights {
-		key := f.Ident
-		if key == "" {
-			key = f.FlightID
-		}
-		if key == "" || seen[key] {
-			continue
-		}
-		seen[key] = true
-		deduped = append(deduped, f)
-	}
-	flights = deduped
-
 	log.Printf("[tracker] radar: %d flights nearby", len(flights))
 
 	// Filter and build FlightWithPos list
@@ -146,11 +202,16 @@ func (t *Tracker) radarTick() {
 			}
 		}
 
-		fwp := FlightWithPos{Flight: f}
+		if t.Enrich != nil {
+			if e, ok := t.Enrich.lookup(f); ok {
+				e.apply(f)
+			}
+		}
+		fwp := FlightWithPos{Flight: f, Airport: airports[i]}
 
 		// If this is the featured flight, poll its position
 		if f.Ident == t.featuredIdent || f.FlightID == t.featuredIdent {
-			pos, err := t.prov.GetFlightPosition(f)
+			pos, err := t.prov.GetFlightPosition(featuredCtx, f)
 			if err == nil && pos != nil {
 				fwp.Position = pos
 
@@ -161,10 +222,10 @@ func (t *Tracker) radarTick() {
 					t.staleCount = 0
 				}
 
-				// Check if out of radar range
+				// Check if out of every radar zone
 				if pos.Latitude != 0 && pos.Longitude != 0 {
-					dist := haversineNM(sfoLat, sfoLon, pos.Latitude, pos.Longitude)
-					if dist > maxDistNM {
+					if station.Nearest(t.Stations, pos.Latitude, pos.Longitude) < 0 {
+						dist := t.Home().DistanceNM(pos.Latitude, pos.Longitude)
 						log.Printf("[tracker] featured %s left radar (%.0fnm), switching", f.DisplayIdent(), dist)
 						t.featuredIdent = ""
 						t.staleCount = 0
@@ -197,13 +258,8 @@ func (t *Tracker) radarTick() {
 			}
 			log.Printf("[tracker] featured → %s", f.DisplayIdent())
 
-			// Backfill aircraft type if missing
-			if f.AircraftType == "" && f.FlightID != "" {
-				go t.backfillAircraftType(f)
-			}
-
 			// Poll position for the newly featured flight
-			pos, err := t.prov.GetFlightPosition(f)
+			pos, err := t.prov.GetFlightPosition(featuredCtx, f)
 			if err == nil && pos != nil {
 				allFlights[i].Position = pos
 			}
@@ -212,6 +268,26 @@ func (t *Tracker) radarTick() {
 		}
 	}
 
+	if t.Enrich != nil {
+		for i := range allFlights {
+			f := allFlights[i].Flight
+			t.Enrich.submit(f, f.Ident == t.featuredIdent || f.FlightID == t.featuredIdent)
+		}
+		t.Enrich.forget(time.Now())
+	}
+
+	t.fillPositions(ctx, allFlights)
+	now := time.Now()
+	for i := range allFlights {
+		t.settlePosition(&allFlights[i], now)
+		t.assignByPosition(&allFlights[i])
+	}
+	if featuredFWP != nil {
+		t.settlePosition(featuredFWP, now)
+		t.assignByPosition(featuredFWP)
+	}
+	t.forgetPositions(allFlights)
+
 	t.setState(State{
 		AllFlights:    allFlights,
 		Featured:      featuredFWP,
@@ -219,64 +295,198 @@ func (t *Tracker) radarTick() {
 	})
 }
 
-// SFO coordinates for distance filtering.
-const (
-	sfoLat = 37.6213
-	sfoLon = -122.3790
-)
+// discover fetches both directions at every station and returns the
+// deduplicated flights, each with the Code of the station it relates to. It
+// fails only if every station's first query does.
+func (t *Tracker) discover(ctx context.Context) ([]provider.Flight, []string, error) {
+	otherDir := provider.Departing
+	if t.direction == provider.Departing {
+		otherDir = provider.Arriving
+	}
 
-// haversineNM computes distance between two lat/lon points in nautical miles.
-func haversineNM(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadiusNM = 3440.065
-	dLat := (lat2 - lat1) * math.Pi / 180
-	dLon := (lon2 - lon1) * math.Pi / 180
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return earthRadiusNM * c
+	seen := make(map[string]bool)
+	var flights []provider.Flight
+	var airports []string
+	var lastErr error
+	ok := false
+	for _, st := range t.Stations {
+		found, err := t.prov.GetFlightsNear(ctx, st.Code, t.direction)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		ok = true
+		// Also fetch the other direction
+		if other, err := t.prov.GetFlightsNear(ctx, st.Code, otherDir); err == nil {
+			found = append(found, other...)
+		}
+
+		// Deduplicate by ident; radar zones overlap, so the same aircraft can
+		// be reported by several stations
+		for _, f := range found {
+			key := f.Ident
+			if key == "" {
+				key = f.FlightID
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			flights = append(flights, f)
+			airports = append(airports, t.airportFor(&f, st.Code))
+		}
+	}
+	if !ok {
+		return nil, nil, lastErr
+	}
+	return flights, airports, nil
 }
 
-// hexdbResponse represents the hexdb.io aircraft lookup response.
-type hexdbResponse struct {
-	ICAOTypeCode     string `json:"ICAOTypeCode"` // e.g. "A359", "B738"
-	Type             string `json:"Type"`         // e.g. "Airbus A350-900"
-	RegisteredOwners string `json:"RegisteredOwners"`
+// airportFor returns the station a flight relates to: the watched station it
+// is flying to or from if its route is known, otherwise def.
+func (t *Tracker) airportFor(f *provider.Flight, def string) string {
+	for _, ref := range []*provider.AirportRef{f.Destination, f.Origin} {
+		if ref == nil {
+			continue
+		}
+		for _, st := range t.Stations {
+			if ref.CodeICAO == st.Code || ref.Code == st.Code || (ref.CodeIATA != "" && ref.CodeIATA == st.Label) {
+				return st.Code
+			}
+		}
+	}
+	return def
 }
 
-// backfillAircraftType looks up the aircraft type from the ICAO24 hex code
-// using the free hexdb.io API and updates the flight.
-func (t *Tracker) backfillAircraftType(flight *provider.Flight) {
-	icao24 := flight.FlightID
-	if len(icao24) != 6 {
-		return // not a valid ICAO24 hex
+// assignByPosition re-tags a flight without a route to a watched station
+// with the nearest station whose radar zone contains it, since overlapping
+// zones otherwise credit it to whichever station was queried first.
+func (t *Tracker) assignByPosition(fwp *FlightWithPos) {
+	if len(t.Stations) < 2 || fwp.Position == nil || t.airportFor(fwp.Flight, "") != "" {
+		return
 	}
+	if n := station.Nearest(t.Stations, fwp.Position.Latitude, fwp.Position.Longitude); n >= 0 {
+		fwp.Airport = t.Stations[n].Code
+	}
+}
 
-	apiURL := fmt.Sprintf("https://hexdb.io/api/v1/aircraft/%s", icao24)
-	client := &http.Client{Timeout: 5 * time.Second}
+// settlePosition keeps the newest position known for the flight: a result
+// observed before the one already shown is discarded, and a flight with no
+// position this tick keeps its last one for up to positionForget. It then
+// marks the flight stale if the position is old.
+func (t *Tracker) settlePosition(fwp *FlightWithPos, now time.Time) {
+	key := enrichKey(fwp.Flight)
+	prev := t.positions[key]
+	switch pos := fwp.Position; {
+	case prev == nil:
+	case pos == nil && prev.Age(now) < positionForget:
+		fwp.Position = prev
+	case pos != nil && pos.Timestamp.Before(prev.Timestamp):
+		fwp.Position = prev
+	}
+	if fwp.Position != nil {
+		t.positions[key] = fwp.Position
+	}
+	fwp.Stale = fwp.Position != nil && fwp.Position.Age(now) > stalePosition
+}
 
-	resp, err := client.Get(apiURL)
-	if err != nil {
-		log.Printf("[tracker] hexdb lookup error for %s: %v", icao24, err)
-		return
+// forgetPositions drops the positions of flights no longer reported.
+func (t *Tracker) forgetPositions(flights []FlightWithPos) {
+	current := make(map[string]bool, len(flights))
+	for _, fwp := range flights {
+		current[enrichKey(fwp.Flight)] = true
+	}
+	for key := range t.positions {
+		if !current[key] {
+			delete(t.positions, key)
+		}
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[tracker] hexdb HTTP %d for %s", resp.StatusCode, icao24)
+// fillPositions sets the position of every flight that doesn't have one from
+// one bulk query per station, if the provider supports it. It runs at background
+// priority so map traffic never costs the featured flight its quota.
+func (t *Tracker) fillPositions(ctx context.Context, flights []FlightWithPos) {
+	bp, ok := t.prov.(provider.BulkPositionProvider)
+	if !ok {
 		return
 	}
-
-	var result hexdbResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Printf("[tracker] hexdb decode error for %s: %v", icao24, err)
+	missing := 0
+	for _, fwp := range flights {
+		if fwp.Position == nil {
+			missing++
+		}
+	}
+	if missing == 0 {
 		return
 	}
 
-	if result.ICAOTypeCode == "" {
-		return
+	bgCtx := provider.WithPriority(ctx, provider.PriorityBackground)
+	filled := 0
+	for _, st := range t.Stations {
+		if filled == missing {
+			break
+		}
+		snap, err := bp.GetPositionsNear(bgCtx, st.Code)
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, errors.ErrUnsupported) {
+				log.Printf("[tracker] bulk positions at %s: %v", st.Label, err)
+			}
+			if errors.Is(err, errors.ErrUnsupported) {
+				return
+			}
+			continue
+		}
+		for i := range flights {
+			if flights[i].Position != nil {
+				continue
+			}
+			if pos, ok := snap.Find(flights[i].Flight); ok {
+				flights[i].Position = pos
+				filled++
+			}
+		}
+	}
+	if filled < missing {
+		log.Printf("[tracker] bulk positions: %d of %d flights located", filled, missing)
+	}
+}
+
+// applyEnrichment updates the published flights matching key with enrichment
+// that arrived after the tick. The snapshot is shared with the UI, so the
+// flights are copied rather than modified.
+func (t *Tracker) applyEnrichment(key string, e Enrichment) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	update := func(fwp FlightWithPos) (FlightWithPos, bool) {
+		if fwp.Flight == nil || enrichKey(fwp.Flight) != key {
+			return fwp, false
+		}
+		f := *fwp.Flight
+		e.apply(&f)
+		fwp.Flight = &f
+		return fwp, true
 	}
 
-	log.Printf("[tracker] backfilled aircraft type for %s: %s", flight.DisplayIdent(), result.ICAOTypeCode)
-	flight.AircraftType = result.ICAOTypeCode
+	var all []FlightWithPos
+	for i, fwp := range t.state.AllFlights {
+		if updated, ok := update(fwp); ok {
+			if all == nil {
+				all = append([]FlightWithPos(nil), t.state.AllFlights...)
+			}
+			all[i] = updated
+		}
+	}
+	if all != nil {
+		t.state.AllFlights = all
+	}
+	if t.state.Featured != nil {
+		if updated, ok := update(*t.state.Featured); ok {
+			t.state.Featured = &updated
+		}
+	}
 }
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,11 +12,15 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/subham/flighttracker/internal/airports"
 	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
 	"github.com/subham/flighttracker/internal/tracker"
 
 	_ "image/jpeg"
@@ -34,6 +39,7 @@ const (
 
 // Game implements ebiten.Game for the flight tracker display.
 type Game struct {
+	ctx        context.Context // cancelled on shutdown; bounds background fetches
 	tracker    *tracker.Tracker
 	mapRender  *MapRenderer
 	logoCache  sync.Map
@@ -44,15 +50,29 @@ type Game struct {
 
 	// Featured flight trail
 	trailPoints    [][2]float64
-	lastFeaturedID string // detect featured flight changes
+	trailTime      time.Time // observation time of the last trail point
+	lastFeaturedID string    // detect featured flight changes
+
+	// Airport filter when watching several stations: -1 shows all of them,
+	// otherwise the index of the one shown. Tab and the arrow keys cycle it.
+	view      int
+	lastCycle time.Time
+
+	// CycleEvery advances the airport filter automatically, for unattended
+	// kiosks. Zero cycles only on key presses.
+	CycleEvery time.Duration
 }
 
-// NewGame creates a new Game instance.
-func NewGame(t *tracker.Tracker) *Game {
+// NewGame creates a new Game instance. The game ends when ctx is cancelled.
+func NewGame(ctx context.Context, t *tracker.Tracker) *Game {
 	g := &Game{
+		ctx:       ctx,
 		tracker:   t,
-		mapRender: NewMapRenderer(mapX, 0, mapWidth, screenHeight),
+		mapRender: NewMapRenderer(ctx, station.Span(t.Stations), mapX, 0, mapWidth, screenHeight),
+		view:      -1,
+		lastCycle: time.Now(),
 	}
+	g.mapRender.SetAirports(t.Stations)
 	g.initFonts()
 
 	// Share the small font with the map renderer for callsign labels
@@ -97,21 +117,35 @@ func (g *Game) initFonts() {
 
 // Update is called every tick (30 TPS).
 func (g *Game) Update() error {
+	if g.ctx.Err() != nil {
+		return ebiten.Termination
+	}
+
+	g.updateView()
+
 	state := g.tracker.GetState()
 
 	// Track featured flight changes for trail management
 	featID := state.FeaturedIdent
 	if featID != g.lastFeaturedID {
-		// New featured flight — reset trail, start from SFO
-		g.trailPoints = [][2]float64{{sfoLat, sfoLon}}
+		// New featured flight — reset trail, start from its airport
+		home := g.tracker.Home()
+		if state.Featured != nil {
+			if i := stationIndex(g.tracker.Stations, state.Featured.Airport); i >= 0 {
+				home = g.tracker.Stations[i]
+			}
+		}
+		g.trailPoints = [][2]float64{{home.Lat, home.Lon}}
+		g.trailTime = time.Time{}
 		g.lastFeaturedID = featID
 	}
 
-	// Record trail point for featured flight
+	// Record trail point for featured flight, in observation order
 	if state.Featured != nil && state.Featured.Position != nil {
 		lat := state.Featured.Position.Latitude
 		lon := state.Featured.Position.Longitude
-		if lat != 0 && lon != 0 {
+		if at := state.Featured.Position.Timestamp; lat != 0 && lon != 0 && !at.Before(g.trailTime) {
+			g.trailTime = at
 			// Only add if position changed (avoid duplicates)
 			if len(g.trailPoints) == 0 ||
 				g.trailPoints[len(g.trailPoints)-1][0] != lat ||
@@ -127,6 +161,54 @@ func (g *Game) Update() error {
 	return nil
 }
 
+// updateView cycles the airport filter on Tab/arrow keys or, if CycleEvery
+// is set, on a timer.
+func (g *Game) updateView() {
+	n := len(g.tracker.Stations)
+	if n < 2 {
+		return
+	}
+	step := 0
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyTab), inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		step = 1
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		step = -1
+	case g.CycleEvery > 0 && time.Since(g.lastCycle) >= g.CycleEvery:
+		step = 1
+	}
+	if step == 0 {
+		return
+	}
+	g.lastCycle = time.Now()
+
+	// Views run all, 0, 1, ..., n-1 and wrap around.
+	g.view = (g.view+1+step+n+1)%(n+1) - 1
+	if g.view < 0 {
+		g.mapRender.SetView(station.Span(g.tracker.Stations))
+	} else {
+		g.mapRender.SetView(g.tracker.Stations[g.view])
+	}
+}
+
+// viewLabel names the current airport filter.
+func (g *Game) viewLabel() string {
+	if g.view < 0 {
+		return station.Span(g.tracker.Stations).Label
+	}
+	return g.tracker.Stations[g.view].Label
+}
+
+// stationIndex returns the index of the station with the given code, or -1.
+func stationIndex(stations []station.Station, code string) int {
+	for i, st := range stations {
+		if st.Code == code {
+			return i
+		}
+	}
+	return -1
+}
+
 // Draw renders the entire screen.
 func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.Black)
@@ -135,6 +217,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	if len(state.AllFlights) == 0 && state.Featured == nil {
 		g.drawWaiting(screen, state)
+		g.drawProviderStatus(screen, state)
 		return
 	}
 
@@ -150,6 +233,54 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	// Divider line between panels
 	vector.DrawFilledRect(screen, leftPanelWidth-1, 0, 2, screenHeight, color.RGBA{0x25, 0x25, 0x25, 0xff}, false)
+
+	g.drawProviderStatus(screen, state)
+}
+
+// drawProviderStatus lists data providers along the bottom of the left panel,
+// coloured by circuit state so it's clear why a provider is being skipped,
+// and when the next poll is due if the scheduler has slowed down.
+func (g *Game) drawProviderStatus(screen *ebiten.Image, state tracker.State) {
+	if g.fontFaceSm == nil {
+		return
+	}
+
+	y := float64(screenHeight - 40)
+	right := float64(leftPanelWidth - 36)
+
+	// Next refresh, when the scheduler has slowed down from the usual pace.
+	// Drawn first, at the right edge, so provider labels can't crowd it out.
+	if wait := time.Until(state.NextPoll); state.PollReason != "normal" && wait > time.Second {
+		label := fmt.Sprintf("next %ds", int(wait.Seconds()))
+		w := textWidth(label, g.fontFaceSm)
+		drawText(screen, label, right-w, y, g.fontFaceSm, color.RGBA{0x44, 0x44, 0x44, 0xff})
+		right -= w + 18
+	}
+
+	if len(state.Providers) < 2 {
+		return
+	}
+	x := 36.0
+	for _, p := range state.Providers {
+		label := p.Name
+		clr := color.RGBA{0x44, 0x44, 0x44, 0xff}
+		switch {
+		case p.State == provider.BreakerOpen:
+			label = fmt.Sprintf("%s %ds", p.Name, int(p.RetryIn.Seconds()))
+			clr = color.RGBA{0xff, 0x66, 0x44, 0xff}
+		case p.State == provider.BreakerHalfOpen, p.ErrorRate > 0.5:
+			clr = color.RGBA{0xdd, 0xaa, 0x33, 0xff}
+		case p.Capacity <= 0:
+			label = p.Name + " quota"
+			clr = color.RGBA{0xdd, 0xaa, 0x33, 0xff}
+		}
+		w := textWidth(label, g.fontFaceSm)
+		if x+w > right {
+			return
+		}
+		drawText(screen, label, x, y, g.fontFaceSm, clr)
+		x += w + 18
+	}
 }
 
 // Layout returns the logical screen dimensions.
@@ -184,7 +315,7 @@ func (g *Game) drawWaiting(screen *ebiten.Image, state tracker.State) {
 	op2.GeoM.Translate(leftPanelWidth/2, screenHeight/2+45)
 	op2.ColorScale.ScaleWithColor(color.RGBA{0x44, 0x44, 0x44, 0xff})
 	op2.PrimaryAlign = text.AlignCenter
-	text.Draw(screen, "SFO Flight Tracker", g.fontFaceSm, op2)
+	text.Draw(screen, station.Span(g.tracker.Stations).Label+" Flight Tracker", g.fontFaceSm, op2)
 }
 
 // drawRadarMap renders all flights on the fixed map.
@@ -199,6 +330,12 @@ func (g *Game) drawRadarMap(screen *ebiten.Image, state tracker.State) {
 		rd := FlightRenderData{
 			Ident:      fwp.Flight.DisplayIdent(),
 			IsFeatured: fwp.Flight.Ident == state.FeaturedIdent || fwp.Flight.FlightID == state.FeaturedIdent,
+			Stale:      fwp.Stale,
+		}
+
+		// Airport filter; the featured flight is always shown
+		if g.view >= 0 && !rd.IsFeatured && fwp.Airport != g.tracker.Stations[g.view].Code {
+			continue
 		}
 
 		if fwp.Position != nil {
@@ -212,14 +349,26 @@ func (g *Game) drawRadarMap(screen *ebiten.Image, state tracker.State) {
 
 	g.mapRender.DrawRadar(screen, flights, g.trailPoints)
 
-	// SFO label
+	// Station labels
 	if g.fontFaceSm != nil {
-		sfoX, sfoY := g.mapRender.GetSFOScreenPos()
-		if sfoX >= mapX && sfoX <= screenWidth {
+		for _, st := range g.tracker.Stations {
+			stX, stY := g.mapRender.ScreenPos(st.Lat, st.Lon)
+			if stX < mapX || stX > screenWidth {
+				continue
+			}
 			op := &text.DrawOptions{}
-			op.GeoM.Translate(float64(sfoX)+12, float64(sfoY)-6)
+			op.GeoM.Translate(float64(stX)+12, float64(stY)-6)
 			op.ColorScale.ScaleWithColor(color.RGBA{0x00, 0xdd, 0xff, 0xff})
-			text.Draw(screen, "SFO", g.fontFaceSm, op)
+			label := st.Label
+			if local, ok := st.LocalTime(time.Now()); ok {
+				label += " " + local.Format("15:04")
+			}
+			text.Draw(screen, label, g.fontFaceSm, op)
+		}
+
+		// Current airport filter
+		if len(g.tracker.Stations) > 1 {
+			drawText(screen, g.viewLabel(), mapX+24, 24, g.fontFaceSm, color.RGBA{0x88, 0x88, 0x88, 0xff})
 		}
 	}
 }
@@ -280,13 +429,18 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, state tracker.State) {
 	}
 
 	// ── Aircraft type ──
-	if flight.AircraftType != "" {
-		acType := ""
-		if flight.OperatorIATA != "" {
-			acType = LookupAircraftType(flight.OperatorIATA, flight.AircraftType)
-		}
-		if acType == "" {
-			acType = flight.AircraftType
+	// Without a type, the ADS-B emitter category still says what kind of
+	// aircraft it is.
+	acType := flight.AircraftName
+	if acType == "" {
+		acType = flight.AircraftType
+	}
+	if acType == "" && flight.Category.Description() != "" {
+		acType = flight.Category.Description() + " aircraft"
+	}
+	if acType != "" {
+		if flight.Registration != "" {
+			acType += " · " + flight.Registration
 		}
 		drawText(screen, acType, 36, y, g.fontFace, color.RGBA{0x99, 0x99, 0x99, 0xff})
 		y += 44
@@ -300,15 +454,22 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, state tracker.State) {
 	if fwp.Position != nil {
 		pos := fwp.Position
 		speedMph := int(float64(pos.Groundspeed) * 1.15078)
-		altFeet := int(pos.Altitude) * 100
+		altFeet := int(pos.BaroAltitude)
 		headingDeg := 0
 		if pos.Heading != nil {
 			headingDeg = *pos.Heading
 		}
 
-		loading := pos.Groundspeed == 0 && pos.Altitude == 0
+		loading := pos.Groundspeed == 0 && pos.BaroAltitude == 0
 
 		labels := []string{"SPEED", "ALTITUDE", "HEADING"}
+		// Near the airport, height above the field says more than altitude.
+		if i := stationIndex(g.tracker.Stations, fwp.Airport); i >= 0 && !loading {
+			st := g.tracker.Stations[i]
+			if agl := altFeet - st.Airport.ElevationFt; st.Airport.ICAO != "" && agl >= 0 && agl < 5000 {
+				labels[1] = strings.ToUpper(fmt.Sprintf("ALTITUDE · %s above %s", formatAltFeet(agl), st.Label))
+			}
+		}
 		values := []string{
 			fmt.Sprintf("%d mph", speedMph),
 			formatAltFeet(altFeet),
@@ -323,7 +484,11 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, state tracker.State) {
 				barW := float32(200 + i*60)
 				drawRoundedRect(screen, 36, float32(y)+6, barW, 44, 8, color.RGBA{0x1a, 0x1a, 0x1a, 0xff})
 			} else {
-				drawText(screen, values[i], 36, y, g.fontFaceXl, color.White)
+				valueClr := color.Color(color.White)
+				if fwp.Stale {
+					valueClr = color.RGBA{0x77, 0x77, 0x77, 0xff}
+				}
+				drawText(screen, values[i], 36, y, g.fontFaceXl, valueClr)
 			}
 			y += 60
 		}
@@ -333,7 +498,7 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, state tracker.State) {
 			y += 4
 			altStatus := ""
 			var altClr color.RGBA
-			switch pos.AltitudeChange {
+			switch pos.Trend() {
 			case "C":
 				altStatus = "▲ CLIMBING"
 				altClr = color.RGBA{0x00, 0xcc, 0x66, 0xff}
@@ -344,24 +509,38 @@ func (g *Game) drawLeftPanel(screen *ebiten.Image, state tracker.State) {
 				altStatus = "━ LEVEL"
 				altClr = color.RGBA{0xaa, 0xaa, 0xaa, 0xff}
 			}
+			if vr := pos.VerticalRate; vr != nil && pos.Trend() != "-" {
+				altStatus += fmt.Sprintf("  %d ft/min", max(int(*vr), -int(*vr)))
+			}
+			if fwp.Stale {
+				// Last heard too long ago to say what it is doing now
+				age := time.Since(pos.LastHeard()).Round(time.Second)
+				altStatus = fmt.Sprintf("LAST SEEN %v AGO", age)
+				altClr = color.RGBA{0x77, 0x77, 0x77, 0xff}
+			}
+			if e := pos.Emergency(); e != "" {
+				altStatus = fmt.Sprintf("SQUAWK %s · %s", pos.Squawk, strings.ToUpper(e))
+				altClr = color.RGBA{0xff, 0x44, 0x44, 0xff}
+			}
 			if altStatus != "" {
 				drawText(screen, altStatus, 36, y, g.fontFaceLg, altClr)
+				y += 44
+			}
+
+			// Positions not broadcast by the aircraft itself are less exact.
+			if pos.Source != "" && pos.Source != provider.SourceADSB {
+				label := strings.ToUpper(string(pos.Source)) + " POSITION"
+				drawText(screen, label, 36, y, g.fontFaceSm, color.RGBA{0x55, 0x55, 0x55, 0xff})
 			}
 		}
 	}
 }
 
-// resolveAirlineName returns the full airline name using the airlines.json dataset.
+// resolveAirlineName returns the full airline name, as filled in by the
+// tracker's enrichment, or the operator code until it arrives.
 func (g *Game) resolveAirlineName(flight *provider.Flight) string {
-	if flight.OperatorIATA != "" {
-		if a, ok := LookupAirlineByIATA(flight.OperatorIATA); ok {
-			return a.Name
-		}
-	}
-	if flight.Operator != "" {
-		if a, ok := LookupAirlineByName(flight.Operator); ok {
-			return a.Name
-		}
+	if flight.AirlineName != "" {
+		return flight.AirlineName
 	}
 	return flight.OperatorName()
 }
@@ -439,7 +618,7 @@ func (g *Game) fetchAirlineLogo(code string, flight *provider.Flight) {
 	}
 
 	for _, u := range urls {
-		img := tryFetchImage(u)
+		img := tryFetchImage(g.ctx, u)
 		if img != nil {
 			ebiImg := ebiten.NewImageFromImage(img)
 			g.logoCache.Store(code, ebiImg)
@@ -449,8 +628,12 @@ func (g *Game) fetchAirlineLogo(code string, flight *provider.Flight) {
 	log.Printf("[ui] could not fetch logo for %s", code)
 }
 
-func tryFetchImage(imgURL string) image.Image {
-	resp, err := http.Get(imgURL)
+func tryFetchImage(ctx context.Context, imgURL string) image.Image {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil || resp.StatusCode != http.StatusOK {
 		if resp != nil {
 			resp.Body.Close()
@@ -504,7 +687,13 @@ func textWidth(s string, face *text.GoTextFace) float64 {
 
 // drawCountryFlag draws a small country flag image next to the route text.
 func (g *Game) drawCountryFlag(screen *ebiten.Image, airport *provider.AirportRef, x, y float64) {
-	countryCode := icaoToCountryCode(airport)
+	countryCode := strings.ToLower(airport.Country)
+	if countryCode == "" {
+		countryCode = airports.Country(airport.CodeICAO)
+	}
+	if countryCode == "" {
+		countryCode = airports.Country(airport.Code)
+	}
 	if countryCode == "" {
 		return
 	}
@@ -528,177 +717,10 @@ func (g *Game) drawCountryFlag(screen *ebiten.Image, airport *provider.AirportRe
 			return
 		}
 		url := fmt.Sprintf("https://flagcdn.com/w80/%s.png", countryCode)
-		img := tryFetchImage(url)
+		img := tryFetchImage(g.ctx, url)
 		if img != nil {
 			ebiImg := ebiten.NewImageFromImage(img)
 			g.logoCache.Store(cacheKey, ebiImg)
 		}
 	}()
 }
-
-// icaoToCountryCode extracts the ISO 3166-1 alpha-2 country code from an airport's ICAO code.
-func icaoToCountryCode(airport *provider.AirportRef) string {
-	code := ""
-	if airport.CodeICAO != "" {
-		code = airport.CodeICAO
-	} else if airport.Code != "" {
-		code = airport.Code
-	}
-	if len(code) < 2 {
-		return ""
-	}
-
-	prefix2 := code[:2]
-	prefix1 := code[:1]
-
-	if cc, ok := icaoPrefixToCountry[prefix2]; ok {
-		return cc
-	}
-	if cc, ok := icaoPrefixToCountry[prefix1]; ok {
-		return cc
-	}
-	return ""
-}
-
-// icaoPrefixToCountry maps ICAO airport code prefixes to ISO 3166-1 alpha-2 country codes.
-var icaoPrefixToCountry = map[string]string{
-	// North America
-	"K":  "us", // USA (continental)
-	"PH": "us", // Hawaii
-	"PA": "us", // Alaska
-	"PG": "us", // Guam
-	"C":  "ca", // Canada
-	"MM": "mx", // Mexico
-
-	// Central America & Caribbean
-	"MG": "gt", // Guatemala
-	"MH": "hn", // Honduras
-	"MN": "ni", // Nicaragua
-	"MR": "cr", // Costa Rica
-	"MP": "pa", // Panama
-	"MK": "jm", // Jamaica
-	"MT": "ht", // Haiti
-	"MD": "do", // Dominican Republic
-	"MU": "cu", // Cuba
-	"TB": "bb", // Barbados
-	"TT": "tt", // Trinidad
-	"TJ": "pr", // Puerto Rico
-	"TI": "vi", // U.S. Virgin Islands
-
-	// South America
-	"SB": "br", // Brazil
-	"SA": "ar", // Argentina
-	"SC": "cl", // Chile
-	"SK": "co", // Colombia
-	"SP": "pe", // Peru
-	"SV": "ve", // Venezuela
-	"SE": "ec", // Ecuador
-	"SU": "uy", // Uruguay
-	"SG": "py", // Paraguay
-	"SL": "bo", // Bolivia
-
-	// Europe
-	"EG": "gb", // United Kingdom
-	"EI": "ie", // Ireland
-	"LF": "fr", // France
-	"ED": "de", // Germany
-	"LI": "it", // Italy
-	"LE": "es", // Spain
-	"LP": "pt", // Portugal
-	"EH": "nl", // Netherlands
-	"EB": "be", // Belgium
-	"LS": "ch", // Switzerland
-	"LO": "at", // Austria
-	"EK": "dk", // Denmark
-	"EN": "no", // Norway
-	"ES": "se", // Sweden
-	"EF": "fi", // Finland
-	"EE": "ee", // Estonia
-	"EV": "lv", // Latvia
-	"EY": "lt", // Lithuania
-	"EP": "pl", // Poland
-	"LK": "cz", // Czech Republic
-	"LZ": "sk", // Slovakia
-	"LH": "hu", // Hungary
-	"LR": "ro", // Romania
-	"LB": "bg", // Bulgaria
-	"LG": "gr", // Greece
-	"LT": "tr", // Turkey
-	"LJ": "si", // Slovenia
-	"LD": "hr", // Croatia
-	"LY": "rs", // Serbia
-	"BI": "is", // Iceland
-	"LU": "md", // Moldova
-	"UK": "ua", // Ukraine
-
-	// Middle East
-	"OE": "sa", // Saudi Arabia
-	"OM": "ae", // UAE
-	"OB": "bh", // Bahrain
-	"OK": "kw", // Kuwait
-	"OO": "om", // Oman
-	"OT": "qa", // Qatar
-	"OI": "ir", // Iran
-	"OJ": "jo", // Jordan
-	"OL": "lb", // Lebanon
-	"OS": "sy", // Syria
-	"LL": "il", // Israel
-
-	// Asia
-	"ZS": "cn", // China (south)
-	"ZB": "cn", // China (north)
-	"ZG": "cn", // China (central)
-	"ZU": "cn", // China (west)
-	"ZW": "cn", // China
-	"ZH": "cn", // China
-	"RJ": "jp", // Japan
-	"RK": "kr", // South Korea
-	"VT": "th", // Thailand
-	"WS": "sg", // Singapore
-	"WM": "my", // Malaysia
-	"WI": "id", // Indonesia
-	"WA": "id", // Indonesia
-	"RP": "ph", // Philippines
-	"VV": "vn", // Vietnam
-	"VH": "hk", // Hong Kong
-	"VM": "mo", // Macau
-	"RC": "tw", // Taiwan
-	"VI": "in", // India (north)
-	"VO": "in", // India (south)
-	"VA": "in", // India (west)
-	"VE": "in", // India (east)
-	"VQ": "bt", // Bhutan
-	"VN": "np", // Nepal
-	"VL": "la", // Laos
-	"VY": "mm", // Myanmar
-	"VC": "lk", // Sri Lanka
-	"OP": "pk", // Pakistan
-
-	// Oceania
-	"Y":  "au", // Australia
-	"NZ": "nz", // New Zealand
-	"NF": "fj", // Fiji
-	"PF": "us", // Midway
-	"PT": "fm", // Micronesia
-
-	// Africa
-	"DA": "dz", // Algeria
-	"DT": "tn", // Tunisia
-	"GM": "ma", // Morocco
-	"HA": "et", // Ethiopia
-	"HK": "ke", // Kenya
-	"HT": "tz", // Tanzania
-	"HR": "rw", // Rwanda
-	"HU": "ug", // Uganda
-	"FA": "za", // South Africa
-	"FV": "zw", // Zimbabwe
-	"DN": "ng", // Nigeria
-	"DG": "gh", // Ghana
-	"FW": "mw", // Malawi
-	"FL": "zm", // Zambia
-	"HE": "eg", // Egypt
-	"HC": "so", // Somalia
-
-	// Russia
-	"U": "ru", // Russia
-}
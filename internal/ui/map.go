@@ -2,6 +2,7 @@ package ui
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"image"
 	"image/color"
@@ -13,22 +14,18 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/subham/flighttracker/internal/provider"
+	"github.com/subham/flighttracker/internal/station"
 
 	_ "image/jpeg"
 	_ "image/png"
 )
 
 const (
-	// SFO coordinates
-	sfoLat = 37.6213
-	sfoLon = -122.3790
-
 	tileSize   = 256
 	maxZoom    = 18
 	minZoom    = 2
 	tileMaxAge = 500 // max cached tiles
-
-	radarZoom = 10.0 // fixed zoom for ~50nm view
 )
 
 // TileKey uniquely identifies a map tile.
@@ -42,15 +39,20 @@ type FlightRenderData struct {
 	Heading    *int
 	Ident      string
 	IsFeatured bool
+	Stale      bool // position is old; drawn faded
 }
 
 // MapRenderer draws an OpenStreetMap tile-based map with flight positions.
 type MapRenderer struct {
+	ctx context.Context // bounds tile fetches
+
 	// Screen region for the map
 	x, y, w, h float32
 
-	// Fixed map state — SFO-centered, constant zoom
-	zoom float64
+	// Map view — centred on a station (or a span of several), constant zoom
+	center   station.Station
+	zoom     float64
+	airports []station.Station // marked on the map
 
 	// Tile cache
 	tileCache sync.Map // map[TileKey]*ebiten.Image
@@ -64,18 +66,33 @@ type MapRenderer struct {
 	labelFont *text.GoTextFace
 }
 
-// NewMapRenderer creates a map renderer for the given screen region.
-func NewMapRenderer(x, y, w, h float32) *MapRenderer {
+// NewMapRenderer creates a map renderer for the given screen region, centred
+// on st at a zoom that fits its radar radius.
+func NewMapRenderer(ctx context.Context, st station.Station, x, y, w, h float32) *MapRenderer {
 	return &MapRenderer{
+		ctx:      ctx,
+		center:   st,
+		airports: []station.Station{st},
 		x:        x,
 		y:        y,
 		w:        w,
 		h:        h,
-		zoom:     radarZoom,
+		zoom:     st.MapZoom(),
 		fetchSem: make(chan struct{}, 4), // max 4 concurrent tile fetches
 	}
 }
 
+// SetView recentres the map on view at the zoom that fits its radar radius.
+func (m *MapRenderer) SetView(view station.Station) {
+	m.center = view
+	m.zoom = view.MapZoom()
+}
+
+// SetAirports sets the stations marked on the map.
+func (m *MapRenderer) SetAirports(airports []station.Station) {
+	m.airports = airports
+}
+
 // SetLabelFont sets the font used for callsign labels on the map.
 func (m *MapRenderer) SetLabelFont(f *text.GoTextFace) {
 	m.labelFont = f
@@ -102,8 +119,8 @@ func tileXYToLatLon(x, y float64, zoom int) (float64, float64) {
 // latLonToScreen converts geographic coordinates to screen pixel coordinates.
 func (m *MapRenderer) latLonToScreen(lat, lon float64) (float32, float32) {
 	z := int(math.Round(m.zoom))
-	// Center tile coordinates (SFO)
-	cx, cy := latLonToTileXY(sfoLat, sfoLon, z)
+	// Center tile coordinates (station)
+	cx, cy := latLonToTileXY(m.center.Lat, m.center.Lon, z)
 	// Point tile coordinates
 	px, py := latLonToTileXY(lat, lon, z)
 
@@ -129,8 +146,8 @@ func (m *MapRenderer) DrawRadar(screen *ebiten.Image, flights []FlightRenderData
 
 	z := int(math.Round(m.zoom))
 
-	// Calculate which tiles we need — centered on SFO
-	cx, cy := latLonToTileXY(sfoLat, sfoLon, z)
+	// Calculate which tiles we need — centered on the station
+	cx, cy := latLonToTileXY(m.center.Lat, m.center.Lon, z)
 
 	// How many tiles fit on screen
 	tilesW := int(math.Ceil(float64(m.w)/tileSize)) + 2
@@ -188,8 +205,10 @@ func (m *MapRenderer) DrawRadar(screen *ebiten.Image, flights []FlightRenderData
 		m.drawTrail(screen, featuredTrail)
 	}
 
-	// Draw SFO marker
-	m.drawAirportMarker(screen, sfoLat, sfoLon)
+	// Draw station markers
+	for _, st := range m.airports {
+		m.drawAirportMarker(screen, st)
+	}
 
 	// Draw all flights
 	for _, f := range flights {
@@ -200,17 +219,23 @@ func (m *MapRenderer) DrawRadar(screen *ebiten.Image, flights []FlightRenderData
 		if !m.IsOnScreen(sx, sy) {
 			continue
 		}
+		size, opacity := 24.0, 0.5
 		if f.IsFeatured {
-			m.drawPlane(screen, f.Lat, f.Lon, f.Heading, 36.0, 1.0)
-		} else {
-			m.drawPlane(screen, f.Lat, f.Lon, f.Heading, 24.0, 0.5)
+			size, opacity = 36.0, 1.0
 		}
+		if f.Stale {
+			opacity *= 0.4
+		}
+		m.drawPlane(screen, f.Lat, f.Lon, f.Heading, size, opacity)
 		// Draw callsign label
 		if m.labelFont != nil && f.Ident != "" {
 			labelClr := color.RGBA{0xcc, 0xcc, 0xcc, 0xaa}
 			if f.IsFeatured {
 				labelClr = color.RGBA{0x00, 0xdd, 0xff, 0xff}
 			}
+			if f.Stale {
+				labelClr = color.RGBA{0x66, 0x66, 0x66, 0x88}
+			}
 			drawText(screen, f.Ident, float64(sx)+20, float64(sy)-8, m.labelFont, labelClr)
 		}
 	}
@@ -237,7 +262,7 @@ func (m *MapRenderer) fetchTile(key TileKey) {
 
 	url := fmt.Sprintf("https://tile.openstreetmap.org/%d/%d/%d.png", key.Z, key.X, key.Y)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(m.ctx, "GET", url, nil)
 	if err != nil {
 		m.tileFetch.Delete(key)
 		return
@@ -298,9 +323,19 @@ func (m *MapRenderer) drawTrail(screen *ebiten.Image, trail [][2]float64) {
 	}
 }
 
-// drawAirportMarker draws SFO dot.
-func (m *MapRenderer) drawAirportMarker(screen *ebiten.Image, lat, lon float64) {
-	x, y := m.latLonToScreen(lat, lon)
+// drawAirportMarker draws the station dot over its runways, where the airport
+// database has their ends.
+func (m *MapRenderer) drawAirportMarker(screen *ebiten.Image, st station.Station) {
+	for _, r := range st.Airport.Runways {
+		if !r.HasEnds() {
+			continue
+		}
+		x1, y1 := m.latLonToScreen(r.LatLow, r.LonLow)
+		x2, y2 := m.latLonToScreen(r.LatHigh, r.LonHigh)
+		vector.StrokeLine(screen, x1, y1, x2, y2, 2, color.RGBA{0x00, 0xbb, 0xff, 0x88}, true)
+	}
+
+	x, y := m.latLonToScreen(st.Lat, st.Lon)
 
 	// Outer glow
 	vector.DrawFilledCircle(screen, x, y, 10, color.RGBA{0x00, 0xbb, 0xff, 0x30}, true)
@@ -355,20 +390,20 @@ func (m *MapRenderer) LoadPlaneIcon(pngData []byte) error {
 	return nil
 }
 
-// GetSFOScreenPos returns the screen position of SFO (for label rendering).
-func (m *MapRenderer) GetSFOScreenPos() (float32, float32) {
-	return m.latLonToScreen(sfoLat, sfoLon)
+// ScreenPos returns the screen position of lat/lon (for label rendering).
+func (m *MapRenderer) ScreenPos(lat, lon float64) (float32, float32) {
+	return m.latLonToScreen(lat, lon)
 }
 
 // FormatSpeed returns a formatted speed string.
-func FormatSpeed(knots int) string {
+func FormatSpeed(knots provider.Knots) string {
 	mph := int(float64(knots) * 1.15078)
 	return fmt.Sprintf("%d mph", mph)
 }
 
 // FormatAltitude returns a formatted altitude string.
-func FormatAltitude(hundredsFeet int) string {
-	feet := hundredsFeet * 100
+func FormatAltitude(alt provider.Feet) string {
+	feet := int(alt)
 	if feet >= 10000 {
 		return fmt.Sprintf("%d,%03d ft", feet/1000, feet%1000)
 	}